@@ -80,7 +80,7 @@ func (q *ChannelMessageQueue) addListMessageToQueue(nodeID string, msg *beehiveM
 }
 
 func (q *ChannelMessageQueue) addMessageToQueue(nodeID string, msg *beehiveModel.Message) {
-	if msg.GetResourceVersion() == "" && !isDeleteMessage(msg) {
+	if msg.GetResourceVersion() == "" && !isDeleteMessage(msg) && !isDebugMessage(msg) {
 		return
 	}
 
@@ -95,7 +95,7 @@ func (q *ChannelMessageQueue) addMessageToQueue(nodeID string, msg *beehiveModel
 
 	item, exist, _ := nodeStore.GetByKey(messageKey)
 
-	if !isDeleteMessage(msg) {
+	if !isDeleteMessage(msg) && !isDebugMessage(msg) {
 		// If the message doesn't exist in the store, then compare it with
 		// the version stored in the database
 		if !exist {
@@ -172,6 +172,16 @@ func isListResource(msg *beehiveModel.Message) bool {
 	return false
 }
 
+// isDebugMessage tells whether the message carries a keadm debug
+// exchange, recognizable by the debug segment in the resource type
+// position (the handler DebugPattern). These are transient
+// request/response messages without a kubernetes object behind them,
+// so the resource version bookkeeping above does not apply to them
+func isDebugMessage(msg *beehiveModel.Message) bool {
+	resourceType, err := edgemessagelayer.GetResourceType(*msg)
+	return err == nil && resourceType == "debug"
+}
+
 func isDeleteMessage(msg *beehiveModel.Message) bool {
 	if msg.GetOperation() == beehiveModel.DeleteOperation {
 		return true
@@ -0,0 +1,50 @@
+package channelq
+
+import (
+	"testing"
+	"time"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	beehiveModel "github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/common/model"
+)
+
+// TestDispatchDebugMessage drives a keadm debug request through
+// DispatchMessage and verifies it reaches the node queue: debug
+// exchanges carry no resource version, so without the exemption in
+// addMessageToQueue they would be dropped silently
+func TestDispatchDebugMessage(t *testing.T) {
+	beehiveContext.InitContext(beehiveContext.MsgCtxTypeChannel)
+	beehiveContext.AddModule(model.SrcCloudHub)
+
+	q := NewChannelMessageQueue(nil)
+	q.Connect(&model.HubInfo{ProjectID: "test", NodeID: "testnode"})
+	go q.DispatchMessage()
+
+	msg := beehiveModel.NewMessage("").
+		BuildRouter("keadm", "resource", "node/testnode/default/debug/collect", "collect")
+	beehiveContext.Send(model.SrcCloudHub, *msg)
+
+	nodeQueue := q.GetNodeQueue("testnode")
+	queued := make(chan string, 1)
+	go func() {
+		key, _ := nodeQueue.Get()
+		queued <- key.(string)
+	}()
+	select {
+	case key := <-queued:
+		if key != "debug/default/collect" {
+			t.Errorf("queued key = %q, want debug/default/collect", key)
+		}
+		item, exist, err := q.GetNodeStore("testnode").GetByKey(key)
+		if err != nil || !exist {
+			t.Fatalf("the debug message is not in the node store: exist=%v err=%v", exist, err)
+		}
+		stored := item.(*beehiveModel.Message)
+		if stored.GetOperation() != "collect" {
+			t.Errorf("stored operation = %q, want collect", stored.GetOperation())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the debug message never reached the node queue")
+	}
+}
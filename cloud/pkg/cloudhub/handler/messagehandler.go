@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
@@ -44,11 +48,24 @@ const (
 const (
 	MsgFormatError = "message format not correct"
 	VolumePattern  = `^\w[-\w.+]*/` + constants.CSIResourceTypeVolume + `/\w[-\w.+]*`
+	DebugPattern   = `^\w[-\w.+]*/debug/\w[-\w.+]*`
+	ReportPattern  = `^node/report/`
 )
 
+// reportDir is where diagnostic bundles uploaded by keadm debug report
+// land on the cloudcore host, one file per upload named after the node
+const reportDir = "/var/lib/kubeedge/reports"
+
 // VolumeRegExp is used to validate the volume resource
 var VolumeRegExp = regexp.MustCompile(VolumePattern)
 
+// DebugRegExp matches the debug resources keadm exchanges with nodes
+// through the unix socket server, whose responses complete a SendSync
+var DebugRegExp = regexp.MustCompile(DebugPattern)
+
+// ReportRegExp matches diagnostic report uploads from keadm debug report
+var ReportRegExp = regexp.MustCompile(ReportPattern)
+
 // MessageHandle processes messages between cloud and edge
 type MessageHandle struct {
 	KeepaliveInterval int
@@ -114,11 +131,18 @@ func (mh *MessageHandle) HandleServer(container *mux.MessageContainer, writer mu
 	}
 
 	// handle the response from edge
-	if VolumeRegExp.MatchString(container.Message.GetResource()) {
+	if VolumeRegExp.MatchString(container.Message.GetResource()) || DebugRegExp.MatchString(container.Message.GetResource()) {
 		beehiveContext.SendResp(*container.Message)
 		return
 	}
 
+	// diagnostic report uploads land in a directory on the cloud side
+	// instead of going to a controller
+	if ReportRegExp.MatchString(container.Message.GetResource()) {
+		saveReportBundle(nodeID, container.Message)
+		return
+	}
+
 	// handle the ack message from edge
 	if container.Message.Router.Operation == beehiveModel.ResponseOperation {
 		if ackChan, ok := mh.MessageAcks.Load(container.Message.Header.ParentID); ok {
@@ -135,6 +159,41 @@ func (mh *MessageHandle) HandleServer(container *mux.MessageContainer, writer mu
 	}
 }
 
+// saveReportBundle stores one uploaded diagnostic bundle under reportDir,
+// named after the node it came from
+func saveReportBundle(nodeID string, msg *beehiveModel.Message) {
+	var bundle struct {
+		Filename string `json:"filename"`
+		Data     string `json:"data"`
+	}
+	content := fmt.Sprintf("%v", msg.GetContent())
+	if err := json.Unmarshal([]byte(content), &bundle); err != nil || bundle.Data == "" {
+		klog.Errorf("malformed report upload from node %s: %v", nodeID, err)
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(bundle.Data)
+	if err != nil {
+		klog.Errorf("malformed report payload from node %s: %v", nodeID, err)
+		return
+	}
+	// the filename comes from the edge, keep it to one path element
+	name := filepath.Base(bundle.Filename)
+	if name == "." || name == string(filepath.Separator) {
+		klog.Errorf("report upload from node %s carries no usable filename", nodeID)
+		return
+	}
+	if err := os.MkdirAll(reportDir, 0700); err != nil {
+		klog.Errorf("failed to create the report directory: %v", err)
+		return
+	}
+	target := filepath.Join(reportDir, nodeID+"-"+name)
+	if err := ioutil.WriteFile(target, data, 0600); err != nil {
+		klog.Errorf("failed to store the report of node %s: %v", nodeID, err)
+		return
+	}
+	klog.Infof("stored diagnostic report %s (%d bytes) from node %s", target, len(data), nodeID)
+}
+
 // OnRegister register node on first connection
 func (mh *MessageHandle) OnRegister(connection conn.Connection) {
 	nodeID := connection.ConnectionState().Headers.Get("node_id")
@@ -4,12 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"k8s.io/klog"
 
 	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
 	"github.com/kubeedge/beehive/pkg/core/model"
 	hubmodel "github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/common/model"
+	"github.com/kubeedge/kubeedge/cloud/pkg/cloudhub/handler"
 	"github.com/kubeedge/kubeedge/common/constants"
 )
 
@@ -26,7 +28,7 @@ func StartServer(address string) {
 		}
 
 		// Send message to edge
-		resp, err := beehiveContext.SendSync(hubmodel.SrcCloudHub, *msg, constants.CSISyncMsgRespTimeout)
+		resp, err := beehiveContext.SendSync(hubmodel.SrcCloudHub, *msg, syncTimeout(msg))
 		if err != nil {
 			klog.Errorf("failed to send message to edge: %v", err)
 			return feedbackError(err, msg)
@@ -45,6 +47,23 @@ func StartServer(address string) {
 	uds.StartServer()
 }
 
+// syncTimeout returns how long to wait for the edge response. Debug
+// requests may carry a timeoutSeconds in their body, filled from the
+// --timeout flag of keadm; CSI requests and debug requests without one
+// keep the CSI default
+func syncTimeout(msg *model.Message) time.Duration {
+	if !handler.DebugRegExp.MatchString(msg.GetResource()) {
+		return constants.CSISyncMsgRespTimeout
+	}
+	var request struct {
+		TimeoutSeconds int64 `json:"timeoutSeconds"`
+	}
+	if err := json.Unmarshal([]byte(fmt.Sprintf("%v", msg.GetContent())), &request); err != nil || request.TimeoutSeconds <= 0 {
+		return constants.CSISyncMsgRespTimeout
+	}
+	return time.Duration(request.TimeoutSeconds) * time.Second
+}
+
 // ExtractMessage extracts message from clients
 func ExtractMessage(context string) (*model.Message, error) {
 	var msg *model.Message
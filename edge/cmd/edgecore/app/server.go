@@ -1,9 +1,13 @@
 package app
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/pprof"
 	"os"
+	"time"
 
 	"github.com/kubeedge/kubeedge/edge/pkg/edgestream"
 
@@ -15,13 +19,16 @@ import (
 	"k8s.io/klog"
 
 	"github.com/kubeedge/beehive/pkg/core"
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
 	"github.com/kubeedge/kubeedge/edge/cmd/edgecore/app/options"
 	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
 	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin"
 	"github.com/kubeedge/kubeedge/edge/pkg/edged"
 	"github.com/kubeedge/kubeedge/edge/pkg/edgehub"
 	"github.com/kubeedge/kubeedge/edge/pkg/eventbus"
 	"github.com/kubeedge/kubeedge/edge/pkg/metamanager"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
 	"github.com/kubeedge/kubeedge/edge/pkg/servicebus"
 	"github.com/kubeedge/kubeedge/edge/test"
 	edgemesh "github.com/kubeedge/kubeedge/edgemesh/pkg"
@@ -33,6 +40,10 @@ import (
 	"github.com/kubeedge/kubeedge/pkg/version/verflag"
 )
 
+// messageStatsPersistPeriod is how often beehive message statistics are
+// written to the configured file
+const messageStatsPersistPeriod = 30 * time.Second
+
 // NewEdgeCoreCommand create edgecore cmd
 func NewEdgeCoreCommand() *cobra.Command {
 	opts := options.NewEdgeCoreOptions()
@@ -80,6 +91,19 @@ offering HTTP client capabilities to components of cloud to reach HTTP servers r
 				}
 			}
 
+			if config.EnableMessageTrace {
+				beehiveContext.EnableMessageTrace(beehiveContext.TraceCapacityDefault)
+			}
+			if config.MessageStatsFile != "" {
+				go beehiveContext.StartStatsPersistence(config.MessageStatsFile, messageStatsPersistPeriod)
+			}
+			if config.EnableProfiling {
+				go startProfilingServer(config.ProfilingPort)
+			}
+			if config.DebugServerSocket != "" {
+				go debugserver.Start(config.DebugServerSocket)
+			}
+
 			registerModules(config)
 			// start all modules
 			core.Run()
@@ -145,6 +169,30 @@ func environmentCheck() error {
 	return nil
 }
 
+// startProfilingServer serves the pprof endpoints on the loopback interface only,
+// so profiles can be taken on the node (e.g. via keadm debug pprof) without
+// exposing runtime internals to the network
+func startProfilingServer(port int32) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/messagetrace", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(beehiveContext.MessageTraceRecords()); err != nil {
+			klog.Errorf("Failed to encode message trace records: %v", err)
+		}
+	})
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	klog.Infof("Start profiling server on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		klog.Errorf("Profiling server stopped: %v", err)
+	}
+}
+
 // registerModules register all the modules started in edgecore
 func registerModules(c *v1alpha1.EdgeCoreConfig) {
 	devicetwin.Register(c.Modules.DeviceTwin, c.Modules.Edged.HostnameOverride)
@@ -157,5 +205,18 @@ func registerModules(c *v1alpha1.EdgeCoreConfig) {
 	edgestream.Register(c.Modules.EdgeStream, c.Modules.Edged.HostnameOverride, c.Modules.Edged.NodeIP)
 	test.Register(c.Modules.DBTest)
 	// Nodte: Need to put it to the end, and wait for all models to register before executing
-	dbm.InitDBConfig(c.DataBase.DriverName, c.DataBase.AliasName, c.DataBase.DataSource)
+	backend := c.Modules.MetaManager.StorageBackend
+	if backend == "" || backend == "sqlite" {
+		dbm.InitDBConfig(c.DataBase.DriverName, c.DataBase.AliasName, c.DataBase.DataSource)
+		dao.SetValueCompression(c.DataBase.CompressValues)
+		return
+	}
+	// the embedded backends replace the database entirely, but devicetwin
+	// and dbtest keep their own relational tables
+	if c.Modules.DeviceTwin.Enable || c.Modules.DBTest.Enable {
+		klog.Fatalf("storage backend %s requires modules.deviceTwin and modules.dbTest to be disabled, they need the relational store", backend)
+	}
+	if err := dao.UseBackend(backend); err != nil {
+		klog.Fatalf("failed to open storage backend %s: %v", backend, err)
+	}
 }
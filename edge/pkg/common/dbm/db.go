@@ -1,12 +1,12 @@
 package dbm
 
 import (
+	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/astaxie/beego/orm"
-
-	//Blank import to run only the init function
-	_ "github.com/mattn/go-sqlite3"
 	"k8s.io/klog"
 )
 
@@ -14,26 +14,155 @@ import (
 var DBAccess orm.Ormer
 var once sync.Once
 
+// driverName remembers the engine the database was opened with, some raw
+// SQL has to differ per engine
+var driverName string
+
+// driverTypes maps the driver setting of the edgecore configuration to the
+// orm driver type; which drivers are actually linked in is decided by the
+// driver_*.go files and their build tags
+var driverTypes = map[string]orm.DriverType{
+	"sqlite3":  orm.DRSqlite,
+	"mysql":    orm.DRMySQL,
+	"postgres": orm.DRPostgres,
+}
+
 // InitDBConfig Init DB info
-func InitDBConfig(driverName, dbName, dataSource string) {
+func InitDBConfig(driver, dbName, dataSource string) {
 	once.Do(func() {
-		if err := orm.RegisterDriver(driverName, orm.DRSqlite); err != nil {
-			klog.Fatalf("Failed to register driver: %v", err)
+		DBAccess = openDB(driver, dbName, dataSource)
+	})
+}
+
+// memoryDBIndex distinguishes the private in-memory databases handed out by InitMemoryDB
+var memoryDBIndex int32
+
+// InitMemoryDB points DBAccess at a fresh private in-memory SQLite database.
+// Unit tests of dao and debug code use it instead of filesystem fixtures,
+// and every call returns an independent empty database. Diskless nodes get
+// the same behavior by configuring dataSource: ":memory:".
+func InitMemoryDB() orm.Ormer {
+	index := atomic.AddInt32(&memoryDBIndex, 1)
+	// beego requires the first registered alias to be named default
+	alias := "default"
+	if _, err := orm.GetDB("default"); err == nil {
+		alias = fmt.Sprintf("memdb%d", index)
+	}
+	DBAccess = openDB("sqlite3", alias, fmt.Sprintf("file:memdb%d?mode=memory&cache=shared", index))
+	return DBAccess
+}
+
+// openDB registers and opens one database and returns its ormer, it is the
+// single place every init path goes through
+func openDB(driver, dbName, dataSource string) orm.Ormer {
+	driverName = driver
+	driverType, ok := driverTypes[driver]
+	if !ok {
+		klog.Fatalf("Unsupported database driver %s, supported drivers are: sqlite3, mysql, postgres", driver)
+	}
+	if driverName == "sqlite3" && !isMemoryDataSource(dataSource) && !strings.Contains(dataSource, "_journal_mode") {
+		// WAL keeps the database consistent through power loss mid-write
+		// and lets concurrent readers (e.g. keadm debug) proceed without
+		// blocking edgecore writes. The mode has to go through the DSN:
+		// the driver applies it when opening each connection, whereas a
+		// PRAGMA statement run through the orm only reaches one pooled
+		// connection and leaves its result cursor open
+		separator := "?"
+		if strings.ContainsRune(dataSource, '?') {
+			separator = "&"
 		}
-		if err := orm.RegisterDataBase(
-			dbName,
-			driverName,
-			dataSource); err != nil {
-			klog.Fatalf("Failed to register db: %v", err)
+		dataSource += separator + "_journal_mode=WAL"
+	}
+	if err := orm.RegisterDriver(driverName, driverType); err != nil {
+		klog.Fatalf("Failed to register driver: %v", err)
+	}
+	if err := orm.RegisterDataBase(
+		dbName,
+		driverName,
+		dataSource); err != nil {
+		klog.Fatalf("Failed to register db: %v", err)
+	}
+	// sync database schema, this creates missing tables on every
+	// supported engine and serves as the migration step for fresh
+	// MariaDB/PostgreSQL databases
+	if err := orm.RunSyncdb(dbName, false, true); err != nil {
+		klog.Errorf("run sync db error %v", err)
+	}
+	// create orm
+	ormer := orm.NewOrm()
+	if err := ormer.Using(dbName); err != nil {
+		klog.Errorf("Using db access error %v", err)
+	}
+	return ormer
+}
+
+// isMemoryDataSource tells whether the data source has no backing file
+func isMemoryDataSource(dataSource string) bool {
+	return dataSource == ":memory:" || strings.Contains(dataSource, "mode=memory")
+}
+
+// RunInTransaction executes fn inside one transaction, so a crash in the
+// middle of a multi-row operation cannot leave the database half-written
+func RunInTransaction(fn func(ormer orm.Ormer) error) error {
+	if err := DBAccess.Begin(); err != nil {
+		return err
+	}
+	if err := fn(DBAccess); err != nil {
+		if rollbackErr := DBAccess.Rollback(); rollbackErr != nil {
+			klog.Errorf("Failed to rollback transaction: %v", rollbackErr)
+		}
+		return err
+	}
+	return DBAccess.Commit()
+}
+
+// UpsertPreservingSQL is UpsertSQL except that the named columns keep
+// their existing value when the row already exists, used for write-once
+// columns like created_at. SQLite (3.24+) and PostgreSQL share the ON
+// CONFLICT syntax, MySQL needs ON DUPLICATE KEY
+func UpsertPreservingSQL(table string, columns []string, keyColumn string, preserved ...string) string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",")
+	columnList := strings.Join(columns, ", ")
+	isPreserved := map[string]bool{keyColumn: true}
+	for _, column := range preserved {
+		isPreserved[column] = true
+	}
+	updates := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if isPreserved[column] {
+			continue
 		}
-		// sync database schema
-		if err := orm.RunSyncdb(dbName, false, true); err != nil {
-			klog.Errorf("run sync db error %v", err)
+		if driverName == "mysql" {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", column, column))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
 		}
-		// create orm
-		DBAccess = orm.NewOrm()
-		if err := DBAccess.Using(dbName); err != nil {
-			klog.Errorf("Using db access error %v", err)
+	}
+	if driverName == "mysql" {
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
+			table, columnList, placeholders, strings.Join(updates, ", "))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+		table, columnList, placeholders, keyColumn, strings.Join(updates, ", "))
+}
+
+// UpsertSQL returns the engine specific statement that inserts a row or
+// replaces it when keyColumn already exists, with one ? placeholder per
+// column; beego adapts the placeholder style per engine
+func UpsertSQL(table string, columns []string, keyColumn string) string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",")
+	columnList := strings.Join(columns, ", ")
+	switch driverName {
+	case "mysql":
+		return fmt.Sprintf("REPLACE INTO %s (%s) VALUES (%s)", table, columnList, placeholders)
+	case "postgres":
+		updates := make([]string, 0, len(columns))
+		for _, column := range columns {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", column, column))
 		}
-	})
+		return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO UPDATE SET %s",
+			table, columnList, placeholders, keyColumn, strings.Join(updates, ", "))
+	default:
+		return fmt.Sprintf("INSERT OR REPLACE INTO %s (%s) VALUES (%s)", table, columnList, placeholders)
+	}
 }
@@ -0,0 +1,9 @@
+// +build dbm_mysql
+
+package dbm
+
+import (
+	//Blank import to run only the init function, built with -tags dbm_mysql
+	//for industrial gateways that already run MariaDB/MySQL
+	_ "github.com/go-sql-driver/mysql"
+)
@@ -0,0 +1,9 @@
+// +build dbm_postgres
+
+package dbm
+
+import (
+	//Blank import to run only the init function, built with -tags dbm_postgres
+	//for industrial gateways that already run PostgreSQL
+	_ "github.com/lib/pq"
+)
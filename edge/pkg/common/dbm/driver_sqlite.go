@@ -0,0 +1,11 @@
+// +build !dbm_exclude_sqlite
+
+package dbm
+
+import (
+	//Blank import to run only the init function. A CGO-free sqlite driver
+	//for static cross builds was considered, but no release of one is
+	//compatible with the Go version this tree pins, so cross compiles
+	//need a C cross toolchain until the module can be upgraded
+	_ "github.com/mattn/go-sqlite3"
+)
@@ -0,0 +1,305 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package debugserver exposes a local unix socket through which keadm can
+// interact with the beehive framework of a running edgecore, e.g. to inject
+// test messages when reproducing sync problems. The socket is only created
+// when debugServerSocket is set in the edgecore configuration and is always
+// restricted to local root access.
+package debugserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+// DefaultSocket is the path keadm connects to when no socket is given
+const DefaultSocket = "/var/lib/kubeedge/debug.sock"
+
+// operations accepted over the debug socket
+const (
+	OpInject = "inject"
+	OpSync   = "sync"
+	OpWatch  = "watch"
+)
+
+// Request is one operation submitted over the debug socket
+type Request struct {
+	// Op selects the operation
+	Op string `json:"op"`
+	// Module is the destination beehive module of an injected message
+	Module string `json:"module,omitempty"`
+	// Resource narrows a sync to one resource key, one namespace/type
+	// prefix or one type; empty syncs every cached resource
+	Resource string `json:"resource,omitempty"`
+	// Sync requests a synchronous injection returning the module response
+	Sync bool `json:"sync,omitempty"`
+	// TimeoutSeconds bounds a synchronous injection, 0 means the beehive default
+	TimeoutSeconds int64 `json:"timeout_seconds,omitempty"`
+	// Message is the beehive message to inject
+	Message *model.Message `json:"message,omitempty"`
+}
+
+// Response reports how a request was handled
+type Response struct {
+	Success bool `json:"success"`
+	// Error carries the failure reason when Success is false
+	Error string `json:"error,omitempty"`
+	// Response is the module reply for synchronous injections
+	Response *model.Message `json:"response,omitempty"`
+	// Synced is how many resources a sync dispatched
+	Synced int `json:"synced,omitempty"`
+}
+
+// Start listens on the given unix socket and serves debug requests until
+// edgecore exits, it is expected to run in its own goroutine
+func Start(socket string) {
+	if err := os.MkdirAll(filepath.Dir(socket), os.ModePerm); err != nil {
+		klog.Errorf("Failed to create debug socket dir: %v", err)
+		return
+	}
+	// remove a stale socket left behind by an earlier edgecore
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		klog.Errorf("Failed to remove stale debug socket %s: %v", socket, err)
+		return
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		klog.Errorf("Failed to listen on debug socket %s: %v", socket, err)
+		return
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		klog.Errorf("Failed to restrict debug socket permission: %v", err)
+		listener.Close()
+		return
+	}
+	klog.Infof("Start debug server on %s", socket)
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			klog.Errorf("Debug server accept error: %v", err)
+			return
+		}
+		go handleConnection(conn)
+	}
+}
+
+// handleConnection serves one keadm session, requests and responses are
+// newline delimited JSON objects
+func handleConnection(conn net.Conn) {
+	defer conn.Close()
+	// a crafted message must never take down edgecore
+	defer func() {
+		if exception := recover(); exception != nil {
+			klog.Warningf("Recover in debug server connection handler, exception: %+v", exception)
+		}
+	}()
+
+	decoder := json.NewDecoder(conn)
+	encoder := json.NewEncoder(conn)
+	for {
+		var request Request
+		if err := decoder.Decode(&request); err != nil {
+			return
+		}
+		// a watch turns the connection into an event stream and ends it
+		if request.Op == OpWatch {
+			watchEvents(encoder, &request)
+			return
+		}
+		encoder.Encode(handleRequest(&request))
+	}
+}
+
+// handleRequest dispatches one debug request
+func handleRequest(request *Request) *Response {
+	switch request.Op {
+	case OpInject:
+		return injectMessage(request)
+	case OpSync:
+		return syncResources(request)
+	default:
+		return &Response{Error: "unknown op " + request.Op}
+	}
+}
+
+// Event is one resource operation observed inside edgecore, streamed to
+// watching keadm sessions
+type Event struct {
+	// Time is when the operation passed through, RFC3339 with sub seconds
+	Time string `json:"time"`
+	// Operation is the beehive operation, e.g. insert, update or delete
+	Operation string `json:"operation"`
+	// Source is the beehive module the message came from
+	Source string `json:"source"`
+	// Resource is the beehive resource, e.g. default/pod/nginx
+	Resource string `json:"resource"`
+}
+
+// eventSubscribers are the channels of the active watches. Publishing
+// never blocks on them, a slow watcher loses events instead of slowing
+// down edgecore
+var (
+	eventMutex       sync.Mutex
+	eventSubscribers = map[chan Event]struct{}{}
+)
+
+// PublishEvent fans one observed operation out to the active watches, it
+// is cheap when nobody watches
+func PublishEvent(operation, source, resource string) {
+	eventMutex.Lock()
+	defer eventMutex.Unlock()
+	if len(eventSubscribers) == 0 {
+		return
+	}
+	event := Event{
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		Operation: operation,
+		Source:    source,
+		Resource:  resource,
+	}
+	for subscriber := range eventSubscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+}
+
+// watchEvents streams the matching events to the connection until the
+// client hangs up
+func watchEvents(encoder *json.Encoder, request *Request) {
+	events := make(chan Event, 64)
+	eventMutex.Lock()
+	eventSubscribers[events] = struct{}{}
+	eventMutex.Unlock()
+	defer func() {
+		eventMutex.Lock()
+		delete(eventSubscribers, events)
+		eventMutex.Unlock()
+	}()
+
+	// the ack tells keadm the stream is live before the first event
+	if err := encoder.Encode(&Response{Success: true}); err != nil {
+		return
+	}
+	for event := range events {
+		if !eventMatches(&event, request.Resource) {
+			continue
+		}
+		if err := encoder.Encode(&event); err != nil {
+			return
+		}
+	}
+}
+
+// eventMatches applies the resource selector of a watch: empty matches
+// everything, a bare type matches its resources, anything else matches
+// as a resource key prefix
+func eventMatches(event *Event, resource string) bool {
+	if resource == "" {
+		return true
+	}
+	if !strings.Contains(resource, "/") {
+		_, resType, _ := splitEventResource(event.Resource)
+		return resType == resource
+	}
+	return strings.HasPrefix(event.Resource, resource)
+}
+
+// splitEventResource splits namespace/type[/name] like the metamanager does
+func splitEventResource(resource string) (string, string, string) {
+	tokens := strings.SplitN(resource, "/", 3)
+	switch len(tokens) {
+	case 2:
+		return tokens[0], tokens[1], ""
+	case 3:
+		return tokens[0], tokens[1], tokens[2]
+	default:
+		return "", "", ""
+	}
+}
+
+// syncResources re-dispatches the stored values of the selected resources
+// to the destination module as update messages, so manual database edits
+// and imports take effect without restarting edgecore
+func syncResources(request *Request) *Response {
+	module := request.Module
+	if module == "" {
+		module = "edged"
+	}
+	metas, err := selectMetas(request.Resource)
+	if err != nil {
+		return &Response{Error: err.Error()}
+	}
+	for _, meta := range *metas {
+		message := model.NewMessage("").
+			BuildRouter("keadm", "", meta.Key, model.UpdateOperation).
+			FillBody(meta.Value)
+		beehiveContext.Send(module, *message)
+	}
+	return &Response{Success: true, Synced: len(*metas)}
+}
+
+// selectMetas resolves the resource selector of a sync request to rows:
+// a full namespace/type/name key, a namespace/type prefix, a bare type,
+// or everything when empty
+func selectMetas(resource string) (*[]dao.Meta, error) {
+	switch strings.Count(resource, "/") {
+	case 0:
+		if resource == "" {
+			return dao.QueryAllMeta("key__contains", "/")
+		}
+		return dao.QueryAllMeta("type", resource)
+	case 1:
+		return dao.QueryAllMeta("key__startswith", resource+"/")
+	case 2:
+		return dao.QueryAllMeta("key", resource)
+	default:
+		return nil, fmt.Errorf("invalid resource selector %q, expected type, namespace/type or namespace/type/name", resource)
+	}
+}
+
+// injectMessage sends the crafted message to the requested module and,
+// for synchronous requests, waits for the module's response
+func injectMessage(request *Request) *Response {
+	if request.Module == "" || request.Message == nil {
+		return &Response{Error: "inject requires module and message"}
+	}
+	if request.Sync {
+		resp, err := beehiveContext.SendSync(request.Module, *request.Message,
+			time.Duration(request.TimeoutSeconds)*time.Second)
+		if err != nil {
+			return &Response{Error: err.Error()}
+		}
+		return &Response{Success: true, Response: &resp}
+	}
+	beehiveContext.Send(request.Module, *request.Message)
+	return &Response{Success: true}
+}
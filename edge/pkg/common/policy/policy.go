@@ -0,0 +1,195 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy implements a small local admission policy for the edge.
+// Site admins declare constraints in a YAML file on the node, edged
+// evaluates them before running a pod and keadm debug policy dry-runs
+// them against manifests. The rules are deliberately declarative, a few
+// named predicates and an image registry whitelist, so a policy file can
+// be reviewed at a glance.
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"k8s.io/klog"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// DefaultPolicyPath is where edged looks for the admission policy; no
+// policy is enforced when the file does not exist
+const DefaultPolicyPath = "/etc/kubeedge/policy.yaml"
+
+// Rule is one constraint of the admission policy. Exactly one of Deny
+// and AllowedRegistries is expected to be set
+type Rule struct {
+	// Name identifies the rule in violation reports
+	Name string `json:"name"`
+	// Deny names a built-in predicate the pod must not match:
+	// privileged, hostNetwork, hostPID, hostIPC or hostPath
+	Deny string `json:"deny,omitempty"`
+	// AllowedRegistries whitelists the registries container images may
+	// come from; images without a registry count as docker.io
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+}
+
+// Policy is the set of rules evaluated against every pod
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Violation reports one rule a pod breaks
+type Violation struct {
+	Rule   string
+	Reason string
+}
+
+// Load reads a policy file
+func Load(path string) (*Policy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %v", path, err)
+	}
+	for _, rule := range policy.Rules {
+		if err := validateRule(&rule); err != nil {
+			return nil, fmt.Errorf("invalid policy %s: %v", path, err)
+		}
+	}
+	return policy, nil
+}
+
+// validateRule rejects rules that would silently never match
+func validateRule(rule *Rule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("every rule needs a name")
+	}
+	if rule.Deny == "" && len(rule.AllowedRegistries) == 0 {
+		return fmt.Errorf("rule %s constrains nothing, set deny or allowedRegistries", rule.Name)
+	}
+	switch rule.Deny {
+	case "", "privileged", "hostNetwork", "hostPID", "hostIPC", "hostPath":
+	default:
+		return fmt.Errorf("rule %s denies unknown predicate %s", rule.Name, rule.Deny)
+	}
+	return nil
+}
+
+var (
+	defaultPolicy     *Policy
+	defaultPolicyOnce sync.Once
+)
+
+// Default returns the policy loaded from DefaultPolicyPath, nil when the
+// file does not exist. A nil policy admits everything
+func Default() *Policy {
+	defaultPolicyOnce.Do(func() {
+		policy, err := Load(DefaultPolicyPath)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				klog.Errorf("ignoring unusable admission policy: %v", err)
+			}
+			return
+		}
+		klog.Infof("loaded admission policy %s with %d rules", DefaultPolicyPath, len(policy.Rules))
+		defaultPolicy = policy
+	})
+	return defaultPolicy
+}
+
+// Admit evaluates the pod against every rule and returns the violations,
+// none on a nil policy
+func (p *Policy) Admit(pod *v1.Pod) []Violation {
+	if p == nil {
+		return nil
+	}
+	var violations []Violation
+	for _, rule := range p.Rules {
+		if reason := rule.check(pod); reason != "" {
+			violations = append(violations, Violation{Rule: rule.Name, Reason: reason})
+		}
+	}
+	return violations
+}
+
+// check evaluates one rule, returning the violation reason or ""
+func (r *Rule) check(pod *v1.Pod) string {
+	switch r.Deny {
+	case "privileged":
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			if container.SecurityContext != nil && container.SecurityContext.Privileged != nil && *container.SecurityContext.Privileged {
+				return fmt.Sprintf("container %s is privileged", container.Name)
+			}
+		}
+	case "hostNetwork":
+		if pod.Spec.HostNetwork {
+			return "pod uses the host network"
+		}
+	case "hostPID":
+		if pod.Spec.HostPID {
+			return "pod shares the host PID namespace"
+		}
+	case "hostIPC":
+		if pod.Spec.HostIPC {
+			return "pod shares the host IPC namespace"
+		}
+	case "hostPath":
+		for _, volume := range pod.Spec.Volumes {
+			if volume.HostPath != nil {
+				return fmt.Sprintf("volume %s mounts host path %s", volume.Name, volume.HostPath.Path)
+			}
+		}
+	}
+	if len(r.AllowedRegistries) > 0 {
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			registry := imageRegistry(container.Image)
+			if !contains(r.AllowedRegistries, registry) {
+				return fmt.Sprintf("image %s comes from registry %s, allowed: %s",
+					container.Image, registry, strings.Join(r.AllowedRegistries, ", "))
+			}
+		}
+	}
+	return ""
+}
+
+// imageRegistry extracts the registry of an image reference, references
+// without one default to docker.io like the runtimes do
+func imageRegistry(image string) string {
+	first := strings.SplitN(image, "/", 2)[0]
+	if strings.ContainsAny(first, ".:") || first == "localhost" {
+		return first
+	}
+	return "docker.io"
+}
+
+// contains tells whether the list holds the value
+func contains(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
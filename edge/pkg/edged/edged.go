@@ -94,6 +94,7 @@ import (
 	"github.com/kubeedge/beehive/pkg/core/model"
 	"github.com/kubeedge/kubeedge/common/constants"
 	"github.com/kubeedge/kubeedge/edge/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/policy"
 	"github.com/kubeedge/kubeedge/edge/pkg/edged/apis"
 	edgecadvisor "github.com/kubeedge/kubeedge/edge/pkg/edged/cadvisor"
 	"github.com/kubeedge/kubeedge/edge/pkg/edged/clcm"
@@ -1215,6 +1216,11 @@ func (e *edged) handlePodListFromEdgeController(content []byte) (err error) {
 func (e *edged) addPod(obj interface{}) {
 	pod := obj.(*v1.Pod)
 	klog.Infof("start sync addition for pod [%s]", pod.Name)
+	if violations := policy.Default().Admit(pod); len(violations) > 0 {
+		klog.Errorf("reject pod [%s] by the local admission policy, rule %s: %s",
+			pod.Name, violations[0].Rule, violations[0].Reason)
+		return
+	}
 	attrs := &lifecycle.PodAdmitAttributes{}
 	attrs.Pod = pod
 	otherpods := e.podManager.GetPods()
@@ -1234,6 +1240,11 @@ func (e *edged) addPod(obj interface{}) {
 func (e *edged) updatePod(obj interface{}) {
 	newPod := obj.(*v1.Pod)
 	klog.Infof("start update pod [%s]", newPod.Name)
+	if violations := policy.Default().Admit(newPod); len(violations) > 0 {
+		klog.Errorf("reject pod [%s] update by the local admission policy, rule %s: %s",
+			newPod.Name, violations[0].Rule, violations[0].Reason)
+		return
+	}
 	key := types.NamespacedName{
 		Namespace: newPod.Namespace,
 		Name:      newPod.Name,
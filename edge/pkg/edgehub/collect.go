@@ -0,0 +1,192 @@
+package edgehub
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"time"
+
+	"k8s.io/klog"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/beehive/pkg/core/model"
+)
+
+const (
+	// OperationCollect marks a cloud message asking this node for a
+	// diagnostic bundle, sent by keadm debug collect on the cloud side
+	OperationCollect = "collect"
+
+	// collectBundleLimit caps the bundle returned over the hub
+	// connection, which is no bulk transfer channel
+	collectBundleLimit = 512 * 1024
+
+	// collectLogLimitDefault caps one harvested log when the request
+	// does not choose its own limit
+	collectLogLimitDefault = 128 * 1024
+
+	// edgecoreLogFile is the file fallback for installations logging to
+	// disk instead of journald
+	edgecoreLogFile = "/var/log/kubeedge/edgecore.log"
+)
+
+// collectRequest is the body of one cloud initiated collection
+type collectRequest struct {
+	// Logs asks for edgecore and container runtime logs in the bundle
+	Logs bool `json:"logs,omitempty"`
+	// Since bounds the harvested logs, a duration like 30m0s
+	Since string `json:"since,omitempty"`
+	// LogLimit caps each harvested log in bytes, keeping the newest end
+	LogLimit int64 `json:"logLimit,omitempty"`
+}
+
+// serveCollectRequest assembles a diagnostic bundle and returns it over
+// the same hub connection, like the tunnel exchanges
+func (eh *EdgeHub) serveCollectRequest(message model.Message) {
+	response, err := eh.buildCollectResponse(parseCollectRequest(&message))
+	if err != nil {
+		klog.Errorf("collect failed: %v", err)
+		response = fmt.Sprintf("collect error: %v", err)
+	}
+	if err := eh.sendToCloud(*message.NewRespByMessage(&message, response)); err != nil {
+		klog.Errorf("failed to return the collect response: %v", err)
+	}
+}
+
+// parseCollectRequest reads the request options out of the message, an
+// empty or unreadable body keeps the defaults
+func parseCollectRequest(message *model.Message) *collectRequest {
+	request := &collectRequest{}
+	data, err := json.Marshal(message.GetContent())
+	if err != nil {
+		return request
+	}
+	if err := json.Unmarshal(data, request); err != nil {
+		// injected content may arrive as a preencoded string
+		json.Unmarshal([]byte(fmt.Sprintf("%v", message.GetContent())), request)
+	}
+	if request.LogLimit <= 0 {
+		request.LogLimit = collectLogLimitDefault
+	}
+	return request
+}
+
+// buildCollectResponse builds the bundle body: the persisted connection
+// status and message statistics plus a small summary, as a tar.gz
+func (eh *EdgeHub) buildCollectResponse(request *collectRequest) (string, error) {
+	var buf bytes.Buffer
+	zipper := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(zipper)
+
+	hostname, _ := os.Hostname()
+	summary, err := json.Marshal(map[string]string{
+		"hostname":    hostname,
+		"collectedAt": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := addCollectFile(archive, "collect.json", summary); err != nil {
+		return "", err
+	}
+	// best effort extras, a bundle from a half set up node is still a bundle
+	if status, err := ioutil.ReadFile(HubStatusPath); err == nil {
+		if err := addCollectFile(archive, "edgehub-status.json", status); err != nil {
+			return "", err
+		}
+	}
+	if stats, err := ioutil.ReadFile(beehiveContext.StatsFilePathDefault); err == nil {
+		if err := addCollectFile(archive, "message-stats.json", stats); err != nil {
+			return "", err
+		}
+	}
+	if request.Logs {
+		for name, log := range harvestLogs(request) {
+			if err := addCollectFile(archive, name, log); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return "", err
+	}
+	if err := zipper.Close(); err != nil {
+		return "", err
+	}
+	if buf.Len() > collectBundleLimit {
+		return "", fmt.Errorf("the bundle is %d bytes, larger than the %d byte limit of the hub connection; narrow --since or lower --log-limit", buf.Len(), collectBundleLimit)
+	}
+	body, err := json.Marshal(map[string]string{
+		"filename": fmt.Sprintf("keadm-collect-%s.tar.gz", time.Now().UTC().Format("20060102-150405")),
+		"data":     base64.StdEncoding.EncodeToString(buf.Bytes()),
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// harvestLogs gathers the edgecore and container runtime logs, from
+// journald where available and from the log file otherwise, each capped
+// at the requested limit keeping the newest end
+func harvestLogs(request *collectRequest) map[string][]byte {
+	logs := map[string][]byte{}
+	for unit, name := range map[string]string{"edgecore": "logs/edgecore.log", "docker": "logs/docker.log"} {
+		if log := harvestJournal(unit, request.Since); len(log) > 0 {
+			logs[name] = tailBytes(log, request.LogLimit)
+		}
+	}
+	if _, ok := logs["logs/edgecore.log"]; !ok {
+		if log, err := ioutil.ReadFile(edgecoreLogFile); err == nil && len(log) > 0 {
+			logs["logs/edgecore.log"] = tailBytes(log, request.LogLimit)
+		}
+	}
+	return logs
+}
+
+// harvestJournal reads the journal of one unit, bounded by since
+func harvestJournal(unit, since string) []byte {
+	args := []string{"-u", unit, "--no-pager", "--output", "short-iso"}
+	if since != "" {
+		// journald takes relative times as a leading minus
+		args = append(args, "--since", "-"+since)
+	}
+	output, err := exec.Command("journalctl", args...).Output()
+	if err != nil {
+		return nil
+	}
+	return output
+}
+
+// tailBytes keeps the newest limit bytes, cut at a line boundary
+func tailBytes(data []byte, limit int64) []byte {
+	if int64(len(data)) <= limit {
+		return data
+	}
+	data = data[int64(len(data))-limit:]
+	if at := bytes.IndexByte(data, '\n'); at >= 0 && at+1 < len(data) {
+		data = data[at+1:]
+	}
+	return data
+}
+
+// addCollectFile writes one file into the bundle
+func addCollectFile(archive *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := archive.Write(data)
+	return err
+}
@@ -2,6 +2,7 @@ package edgehub
 
 import (
 	"crypto/tls"
+	"fmt"
 	"sync"
 	"time"
 
@@ -22,6 +23,11 @@ const (
 	ModuleNameEdgeHub = "websocket"
 )
 
+// OperationSimulateOffline asks edgehub to cleanly sever the connection
+// to the cloud and stay disconnected for the duration in the message
+// body, so offline autonomy can be tested without touching firewalls
+const OperationSimulateOffline = "simulate_offline"
+
 var HasTLSTunnelCerts = make(chan bool, 1)
 
 //EdgeHub defines edgehub object structure
@@ -31,6 +37,17 @@ type EdgeHub struct {
 	syncKeeper    map[string]chan model.Message
 	keeperLock    sync.RWMutex
 	enable        bool
+	// offlineUntil holds the deadline of a simulated offline period, the
+	// zero time when none is active
+	offlineUntil time.Time
+	simulateLock sync.Mutex
+	// tunnelPorts are the local ports keadm opened for cloud initiated
+	// tunnel exchanges, guarded by tunnelLock
+	tunnelPorts map[int]bool
+	tunnelLock  sync.Mutex
+	// stats persists reconnect counts, disconnect reasons and message
+	// counters for keadm debug hub status
+	stats *hubStatsRecorder
 }
 
 func newEdgeHub(enable bool) *EdgeHub {
@@ -38,6 +55,7 @@ func newEdgeHub(enable bool) *EdgeHub {
 		reconnectChan: make(chan struct{}),
 		syncKeeper:    make(map[string]chan model.Message),
 		enable:        enable,
+		stats:         newHubStatsRecorder(),
 	}
 }
 
@@ -102,6 +120,7 @@ func (eh *EdgeHub) Start() {
 		}
 		// execute hook func after connect
 		eh.pubConnectInfo(true)
+		eh.stats.connected()
 		go eh.routeToEdge()
 		go eh.routeToCloud()
 		go eh.keepalive()
@@ -113,6 +132,7 @@ func (eh *EdgeHub) Start() {
 
 		// execute hook fun after disconnect
 		eh.pubConnectInfo(false)
+		eh.stats.disconnected()
 
 		// sleep one period of heartbeat, then try to connect cloud hub again
 		time.Sleep(time.Duration(config.Config.Heartbeat) * time.Second * 2)
@@ -126,5 +146,56 @@ func (eh *EdgeHub) Start() {
 				break clean
 			}
 		}
+
+		// a simulated offline period keeps the hub disconnected until its
+		// deadline, messages to the cloud queue up in the module channel
+		// and sync once the connection is back
+		eh.waitSimulatedOffline()
+	}
+}
+
+// waitSimulatedOffline blocks until an active simulated offline period
+// ends, returning immediately when none is active
+func (eh *EdgeHub) waitSimulatedOffline() {
+	for {
+		eh.simulateLock.Lock()
+		remaining := time.Until(eh.offlineUntil)
+		eh.simulateLock.Unlock()
+		if remaining <= 0 {
+			return
+		}
+		klog.Warningf("simulated offline, reconnecting to the cloud in %v", remaining)
+		select {
+		case <-beehiveContext.Done():
+			return
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// startSimulatedOffline parses the duration of a simulate_offline message,
+// arms the offline deadline and severs the current connection. It reports
+// whether the deadline was armed: a rejected duration leaves the
+// connection up and must not end the route goroutine
+func (eh *EdgeHub) startSimulatedOffline(message *model.Message) bool {
+	duration, err := time.ParseDuration(fmt.Sprintf("%v", message.GetContent()))
+	if err != nil || duration <= 0 {
+		klog.Errorf("invalid simulated offline duration %v: %v", message.GetContent(), err)
+		if message.IsSync() {
+			beehiveContext.SendResp(*message.NewRespByMessage(message,
+				fmt.Sprintf("invalid duration %v", message.GetContent())))
+		}
+		return false
+	}
+	eh.simulateLock.Lock()
+	eh.offlineUntil = time.Now().Add(duration)
+	eh.simulateLock.Unlock()
+	if message.IsSync() {
+		beehiveContext.SendResp(*message.NewRespByMessage(message,
+			fmt.Sprintf("offline for %v", duration)))
 	}
+	klog.Warningf("simulating offline for %v on request of keadm", duration)
+	eh.stats.disconnecting(fmt.Sprintf("simulated offline for %v", duration))
+	eh.reconnectChan <- struct{}{}
+	return true
 }
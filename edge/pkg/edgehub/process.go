@@ -129,6 +129,16 @@ func (eh *EdgeHub) sendToKeepChannel(message model.Message) error {
 }
 
 func (eh *EdgeHub) dispatch(message model.Message) error {
+	// tunnel exchanges are served by edgehub itself instead of a module
+	if message.GetOperation() == OperationTunnel {
+		go eh.serveTunnelRequest(message)
+		return nil
+	}
+	// so are cloud initiated diagnostic collections
+	if message.GetOperation() == OperationCollect {
+		go eh.serveCollectRequest(message)
+		return nil
+	}
 	// TODO: dispatch message by the message type
 	md, ok := groupMap[message.GetGroup()]
 	if !ok {
@@ -155,10 +165,12 @@ func (eh *EdgeHub) routeToEdge() {
 		message, err := eh.chClient.Receive()
 		if err != nil {
 			klog.Errorf("websocket read error: %v", err)
+			eh.stats.disconnecting(fmt.Sprintf("read error: %v", err))
 			eh.reconnectChan <- struct{}{}
 			return
 		}
 
+		eh.stats.messageReceived()
 		klog.Infof("received msg from cloud-hub:%+v", message)
 		err = eh.dispatch(message)
 		if err != nil {
@@ -175,6 +187,7 @@ func (eh *EdgeHub) sendToCloud(message model.Message) error {
 		klog.Errorf("failed to send message: %v", err)
 		return fmt.Errorf("failed to send message, error: %v", err)
 	}
+	eh.stats.messageSent()
 
 	syncKeep := func(message model.Message) {
 		tempChannel := eh.addKeepChannel(message.GetID())
@@ -212,10 +225,28 @@ func (eh *EdgeHub) routeToCloud() {
 			continue
 		}
 
+		// a simulated offline request severs the connection instead of
+		// going to the cloud; only when the deadline was actually armed
+		// does the route goroutine end like on an error, a rejected
+		// duration keeps the route running
+		if message.GetOperation() == OperationSimulateOffline {
+			if eh.startSimulatedOffline(&message) {
+				return
+			}
+			continue
+		}
+
+		// tunnel port control stays on the edge as well
+		if message.GetOperation() == OperationTunnelAllow || message.GetOperation() == OperationTunnelRevoke {
+			eh.handleTunnelControl(&message)
+			continue
+		}
+
 		// post message to cloud hub
 		err = eh.sendToCloud(message)
 		if err != nil {
 			klog.Errorf("failed to send message to cloud: %v", err)
+			eh.stats.disconnecting(fmt.Sprintf("write error: %v", err))
 			eh.reconnectChan <- struct{}{}
 			return
 		}
@@ -238,9 +269,11 @@ func (eh *EdgeHub) keepalive() {
 		err := eh.sendToCloud(*msg)
 		if err != nil {
 			klog.Errorf("websocket write error: %v", err)
+			eh.stats.disconnecting(fmt.Sprintf("keepalive write error: %v", err))
 			eh.reconnectChan <- struct{}{}
 			return
 		}
+		eh.stats.flush(eh.pendingSyncCount())
 
 		time.Sleep(time.Duration(config.Config.Heartbeat) * time.Second)
 	}
@@ -0,0 +1,167 @@
+package edgehub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// HubStatusPath is where the connection statistics are persisted, so
+// keadm debug hub status can read them even while edgecore is down
+const HubStatusPath = "/var/lib/kubeedge/edgehub-status.json"
+
+// hubHistoryLength bounds how many past connections are kept
+const hubHistoryLength = 10
+
+// HubConnection is one past connection to the cloud
+type HubConnection struct {
+	ConnectedAt      time.Time `json:"connectedAt"`
+	DisconnectedAt   time.Time `json:"disconnectedAt"`
+	DisconnectReason string    `json:"disconnectReason"`
+}
+
+// HubStatus is the persisted connection state of edgehub
+type HubStatus struct {
+	Connected      bool      `json:"connected"`
+	ConnectedSince time.Time `json:"connectedSince,omitempty"`
+	// Reconnects counts every successful connect, the first one of a
+	// fresh node included, and survives edgecore restarts
+	Reconnects           int             `json:"reconnects"`
+	LastDisconnectTime   time.Time       `json:"lastDisconnectTime,omitempty"`
+	LastDisconnectReason string          `json:"lastDisconnectReason,omitempty"`
+	MessagesToCloud      int64           `json:"messagesToCloud"`
+	MessagesFromCloud    int64           `json:"messagesFromCloud"`
+	PendingSyncMessages  int             `json:"pendingSyncMessages"`
+	UpdatedAt            time.Time       `json:"updatedAt"`
+	History              []HubConnection `json:"history,omitempty"`
+}
+
+// hubStatsRecorder tracks the connection statistics and persists them;
+// a nil recorder, as in hubs built directly by tests, records nothing
+type hubStatsRecorder struct {
+	lock   sync.Mutex
+	status HubStatus
+	// pendingReason is what the routing goroutine observed right before
+	// asking for a reconnect, consumed by disconnected
+	pendingReason string
+}
+
+func newHubStatsRecorder() *hubStatsRecorder {
+	recorder := &hubStatsRecorder{}
+	// the counters and history survive an edgecore restart, a corrupt or
+	// missing file simply starts them over
+	if data, err := ioutil.ReadFile(HubStatusPath); err == nil {
+		_ = json.Unmarshal(data, &recorder.status)
+	}
+	recorder.status.Connected = false
+	recorder.status.ConnectedSince = time.Time{}
+	return recorder
+}
+
+// connected records a successful connect
+func (r *hubStatsRecorder) connected() {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.status.Connected = true
+	r.status.ConnectedSince = time.Now()
+	r.status.Reconnects++
+	r.pendingReason = ""
+	r.persistLocked()
+}
+
+// disconnecting records why a routing goroutine asks for a reconnect,
+// before the reconnect happens
+func (r *hubStatsRecorder) disconnecting(reason string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.pendingReason = reason
+}
+
+// disconnected closes the current connection entry
+func (r *hubStatsRecorder) disconnected() {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	reason := r.pendingReason
+	if reason == "" {
+		reason = "unknown"
+	}
+	now := time.Now()
+	r.status.History = append(r.status.History, HubConnection{
+		ConnectedAt:      r.status.ConnectedSince,
+		DisconnectedAt:   now,
+		DisconnectReason: reason,
+	})
+	if len(r.status.History) > hubHistoryLength {
+		r.status.History = r.status.History[len(r.status.History)-hubHistoryLength:]
+	}
+	r.status.Connected = false
+	r.status.ConnectedSince = time.Time{}
+	r.status.LastDisconnectTime = now
+	r.status.LastDisconnectReason = reason
+	r.persistLocked()
+}
+
+// messageSent counts a message that reached the cloud connection
+func (r *hubStatsRecorder) messageSent() {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.status.MessagesToCloud++
+}
+
+// messageReceived counts a message the cloud delivered
+func (r *hubStatsRecorder) messageReceived() {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.status.MessagesFromCloud++
+}
+
+// flush persists the counters between transitions, called from the
+// keepalive loop with the number of sync messages awaiting a response
+func (r *hubStatsRecorder) flush(pendingSyncMessages int) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.status.PendingSyncMessages = pendingSyncMessages
+	r.persistLocked()
+}
+
+// persistLocked writes the status atomically, callers hold the lock
+func (r *hubStatsRecorder) persistLocked() {
+	r.status.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(&r.status, "", "  ")
+	if err != nil {
+		return
+	}
+	// statistics must never take the hub down, failures are ignored
+	tmp := HubStatusPath + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, HubStatusPath)
+}
+
+// pendingSyncCount is the number of sync messages awaiting a response
+func (eh *EdgeHub) pendingSyncCount() int {
+	eh.keeperLock.RLock()
+	defer eh.keeperLock.RUnlock()
+	return len(eh.syncKeeper)
+}
@@ -0,0 +1,133 @@
+package edgehub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"time"
+
+	"k8s.io/klog"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/beehive/pkg/core/model"
+)
+
+const (
+	// OperationTunnelAllow is injected by keadm to open a local port for
+	// cloud initiated tunnel requests
+	OperationTunnelAllow = "tunnel_allow"
+	// OperationTunnelRevoke closes the port again
+	OperationTunnelRevoke = "tunnel_revoke"
+	// OperationTunnel marks a cloud message carrying one tunnel exchange
+	OperationTunnel = "tunnel"
+
+	// tunnelDialTimeout bounds the dial of the local service
+	tunnelDialTimeout = 5 * time.Second
+	// tunnelReadTimeout bounds how long a tunnel exchange may take
+	tunnelReadTimeout = 30 * time.Second
+	// tunnelResponseLimit caps one relayed response, the hub connection
+	// is no bulk transfer channel
+	tunnelResponseLimit = 1 << 20
+)
+
+// tunnelRequest is the body of one cloud initiated tunnel exchange
+type tunnelRequest struct {
+	Port int    `json:"port"`
+	Data string `json:"data"`
+}
+
+// handleTunnelControl applies a keadm injected allow or revoke of a
+// local tunnel port
+func (eh *EdgeHub) handleTunnelControl(message *model.Message) {
+	port, err := strconv.Atoi(fmt.Sprintf("%v", message.GetContent()))
+	if err != nil || port <= 0 || port > 65535 {
+		eh.respondTo(message, fmt.Sprintf("invalid tunnel port %v", message.GetContent()))
+		return
+	}
+	eh.tunnelLock.Lock()
+	if eh.tunnelPorts == nil {
+		eh.tunnelPorts = map[int]bool{}
+	}
+	if message.GetOperation() == OperationTunnelAllow {
+		eh.tunnelPorts[port] = true
+	} else {
+		delete(eh.tunnelPorts, port)
+	}
+	eh.tunnelLock.Unlock()
+	klog.Infof("tunnel port %d %sed", port, message.GetOperation())
+	eh.respondTo(message, fmt.Sprintf("tunnel port %d %sed", port, message.GetOperation()))
+}
+
+// respondTo answers a sync injection, fire-and-forget ones get nothing
+func (eh *EdgeHub) respondTo(message *model.Message, content string) {
+	if !message.IsSync() {
+		return
+	}
+	beehiveContext.SendResp(*message.NewRespByMessage(message, content))
+}
+
+// tunnelPortAllowed tells whether keadm opened the port
+func (eh *EdgeHub) tunnelPortAllowed(port int) bool {
+	eh.tunnelLock.Lock()
+	defer eh.tunnelLock.Unlock()
+	return eh.tunnelPorts[port]
+}
+
+// serveTunnelRequest relays one cloud initiated exchange to a local
+// service and returns the response over the same hub connection
+func (eh *EdgeHub) serveTunnelRequest(message model.Message) {
+	response, err := eh.relayTunnelRequest(&message)
+	if err != nil {
+		klog.Errorf("tunnel exchange failed: %v", err)
+		response = fmt.Sprintf("tunnel error: %v", err)
+	}
+	if err := eh.sendToCloud(*message.NewRespByMessage(&message, response)); err != nil {
+		klog.Errorf("failed to return the tunnel response: %v", err)
+	}
+}
+
+// relayTunnelRequest performs the local half of one tunnel exchange
+func (eh *EdgeHub) relayTunnelRequest(message *model.Message) (string, error) {
+	data, err := json.Marshal(message.GetContent())
+	if err != nil {
+		return "", err
+	}
+	request := tunnelRequest{}
+	if err := json.Unmarshal(data, &request); err != nil {
+		// injected content may arrive as a preencoded string
+		if err := json.Unmarshal([]byte(fmt.Sprintf("%v", message.GetContent())), &request); err != nil {
+			return "", fmt.Errorf("malformed tunnel request: %v", err)
+		}
+	}
+	if !eh.tunnelPortAllowed(request.Port) {
+		return "", fmt.Errorf("port %d is not opened for tunneling, run keadm debug tunnel on the node", request.Port)
+	}
+	payload, err := base64.StdEncoding.DecodeString(request.Data)
+	if err != nil {
+		return "", fmt.Errorf("malformed tunnel payload: %v", err)
+	}
+
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", request.Port), tunnelDialTimeout)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach the local service on port %d: %v", request.Port, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(tunnelReadTimeout))
+	if _, err := conn.Write(payload); err != nil {
+		return "", err
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.CloseWrite()
+	}
+	reply, err := ioutil.ReadAll(io.LimitReader(conn, tunnelResponseLimit))
+	if err != nil {
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() || len(reply) == 0 {
+			return "", err
+		}
+	}
+	return base64.StdEncoding.EncodeToString(reply), nil
+}
@@ -0,0 +1,150 @@
+package dao
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/kvstore"
+)
+
+func init() {
+	// the default relational store doubles as a kvstore backend, so code
+	// programming against the Store interface works without build tags
+	kvstore.Register("sqlite", func() (kvstore.Store, error) {
+		return &sqliteStore{}, nil
+	})
+}
+
+// backend is the key-value store the dao routes through instead of the
+// ORM when a non-relational backend is selected, nil for SQLite
+var (
+	backend     kvstore.Store
+	backendName string
+)
+
+// UseBackend selects the storage backend of the dao. The empty name and
+// "sqlite" keep the direct ORM path; anything else must be a registered
+// kvstore backend, compiled in behind its build tag
+func UseBackend(name string) error {
+	if name == "" || name == "sqlite" {
+		backend = nil
+		backendName = ""
+		return nil
+	}
+	store, err := kvstore.New(name)
+	if err != nil {
+		return err
+	}
+	backend = store
+	backendName = name
+	return nil
+}
+
+// errBackendUnsupported tells the caller an operation needs the columns
+// of the relational store and has no equivalent on a key-value backend
+func errBackendUnsupported(operation string) error {
+	return fmt.Errorf("%s is not supported on the %s backend, it needs the columns of the sqlite store", operation, backendName)
+}
+
+// backendPut writes one meta row as a key-value entry, the timestamp
+// columns and value compression of the relational path do not apply
+func backendPut(meta *Meta) error {
+	return backend.Put(&kvstore.Entry{Key: meta.Key, Type: meta.Type, Value: meta.Value})
+}
+
+// backendQuery answers the dao query filters from the key-value backend.
+// Only the filters the dao callers actually use are translated; entries
+// come back ordered by key like the paged SQL queries
+func backendQuery(field string, condition string) (*[]Meta, error) {
+	var entries []kvstore.Entry
+	var err error
+	switch field {
+	case "key":
+		var entry *kvstore.Entry
+		entry, err = backend.Get(condition)
+		if entry != nil {
+			entries = []kvstore.Entry{*entry}
+		}
+	case "type":
+		entries, err = backend.List(condition)
+	case "key__contains", "key__startswith":
+		entries, err = backend.List("")
+		if err == nil {
+			matched := entries[:0]
+			for _, entry := range entries {
+				if field == "key__contains" && !strings.Contains(entry.Key, condition) {
+					continue
+				}
+				if field == "key__startswith" && !strings.HasPrefix(entry.Key, condition) {
+					continue
+				}
+				matched = append(matched, entry)
+			}
+			entries = matched
+		}
+	default:
+		return nil, errBackendUnsupported(fmt.Sprintf("the %s filter", field))
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	metas := make([]Meta, 0, len(entries))
+	for _, entry := range entries {
+		metas = append(metas, Meta{Key: entry.Key, Type: entry.Type, Value: entry.Value})
+	}
+	return &metas, nil
+}
+
+// sqliteStore adapts the meta dao to the Store interface, change events
+// are fanned out in-process since SQLite has no native notifications
+type sqliteStore struct {
+	kvstore.Notifier
+}
+
+func (s *sqliteStore) Get(key string) (*kvstore.Entry, error) {
+	metas, err := QueryAllMeta("key", key)
+	if err != nil {
+		return nil, err
+	}
+	if len(*metas) == 0 {
+		return nil, nil
+	}
+	meta := (*metas)[0]
+	return &kvstore.Entry{Key: meta.Key, Type: meta.Type, Value: meta.Value}, nil
+}
+
+func (s *sqliteStore) Put(entry *kvstore.Entry) error {
+	if err := InsertOrUpdate(&Meta{Key: entry.Key, Type: entry.Type, Value: entry.Value}); err != nil {
+		return err
+	}
+	s.Notify(kvstore.Event{Op: kvstore.EventPut, Entry: *entry})
+	return nil
+}
+
+func (s *sqliteStore) Delete(key string) error {
+	if err := DeleteMetaByKey(key); err != nil {
+		return err
+	}
+	s.Notify(kvstore.Event{Op: kvstore.EventDelete, Entry: kvstore.Entry{Key: key}})
+	return nil
+}
+
+func (s *sqliteStore) List(resType string) ([]kvstore.Entry, error) {
+	var metas *[]Meta
+	var err error
+	if resType == "" {
+		metas, err = QueryAllMeta("key__contains", "/")
+	} else {
+		metas, err = QueryAllMeta("type", resType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]kvstore.Entry, 0, len(*metas))
+	for _, meta := range *metas {
+		entries = append(entries, kvstore.Entry{Key: meta.Key, Type: meta.Type, Value: meta.Value})
+	}
+	return entries, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"testing"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/kvstore"
+)
+
+// mapStore is an in-memory Store standing in for the embedded backends,
+// which hide behind build tags and real database files
+type mapStore struct {
+	kvstore.Notifier
+	entries map[string]kvstore.Entry
+}
+
+func (s *mapStore) Get(key string) (*kvstore.Entry, error) {
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (s *mapStore) Put(entry *kvstore.Entry) error {
+	s.entries[entry.Key] = *entry
+	return nil
+}
+
+func (s *mapStore) Delete(key string) error {
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *mapStore) List(resType string) ([]kvstore.Entry, error) {
+	var entries []kvstore.Entry
+	for _, entry := range s.entries {
+		if resType != "" && entry.Type != resType {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// TestBackendRouting exercises the dao against a registered key-value
+// backend: the same calls metamanager makes must reach the Store instead
+// of the ORM, and fall back to the ORM once the backend is deselected
+func TestBackendRouting(t *testing.T) {
+	store := &mapStore{entries: map[string]kvstore.Entry{}}
+	kvstore.Register("maptest", func() (kvstore.Store, error) { return store, nil })
+	if err := UseBackend("maptest"); err != nil {
+		t.Fatalf("UseBackend failed: %v", err)
+	}
+	defer func() {
+		if err := UseBackend(""); err != nil {
+			t.Fatalf("deselecting the backend failed: %v", err)
+		}
+	}()
+
+	if err := SaveMeta(&Meta{Key: "default/pod/nginx", Type: "pod", Value: `{"a":1}`}); err != nil {
+		t.Fatalf("SaveMeta failed: %v", err)
+	}
+	if err := BulkInsertOrUpdate([]Meta{
+		{Key: "default/pod/redis", Type: "pod", Value: `{"b":2}`},
+		{Key: "kube-system/configmap/coredns", Type: "configmap", Value: `{"c":3}`},
+	}); err != nil {
+		t.Fatalf("BulkInsertOrUpdate failed: %v", err)
+	}
+	if len(store.entries) != 3 {
+		t.Fatalf("the store holds %d entries, want 3", len(store.entries))
+	}
+
+	values, err := QueryMeta("key", "default/pod/nginx")
+	if err != nil {
+		t.Fatalf("QueryMeta failed: %v", err)
+	}
+	if len(*values) != 1 || (*values)[0] != `{"a":1}` {
+		t.Fatalf("QueryMeta returned %v", *values)
+	}
+
+	metas, err := QueryAllMeta("type", "pod")
+	if err != nil {
+		t.Fatalf("QueryAllMeta failed: %v", err)
+	}
+	if len(*metas) != 2 || (*metas)[0].Key != "default/pod/nginx" {
+		t.Fatalf("QueryAllMeta returned %v, want the two pods ordered by key", *metas)
+	}
+
+	page, err := QueryMetaPage("pod", "default", 1, 1)
+	if err != nil {
+		t.Fatalf("QueryMetaPage failed: %v", err)
+	}
+	if len(*page) != 1 || (*page)[0].Key != "default/pod/redis" {
+		t.Fatalf("QueryMetaPage returned %v, want only default/pod/redis", *page)
+	}
+
+	size, err := SizeOfMeta("pod", "default")
+	if err != nil {
+		t.Fatalf("SizeOfMeta failed: %v", err)
+	}
+	if want := int64(len(`{"a":1}`) + len(`{"b":2}`)); size != want {
+		t.Fatalf("SizeOfMeta returned %d, want %d", size, want)
+	}
+
+	if err := UpdateMetaField("default/pod/nginx", "value", "x"); err == nil {
+		t.Fatal("UpdateMetaField must report that the backend has no columns")
+	}
+
+	if err := DeleteMetaByKeys([]string{"default/pod/nginx", "default/pod/redis"}); err != nil {
+		t.Fatalf("DeleteMetaByKeys failed: %v", err)
+	}
+	if len(store.entries) != 1 {
+		t.Fatalf("the store holds %d entries after the delete, want 1", len(store.entries))
+	}
+}
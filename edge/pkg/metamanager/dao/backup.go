@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"errors"
+	"time"
+
+	"k8s.io/klog"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// ErrNoBackup is returned when rolling back a key that was never backed up
+var ErrNoBackup = errors.New("no backup exists for the resource")
+
+// constant backup table name reference
+const (
+	BackupTableName = "meta_backup"
+)
+
+// MetaBackup holds the previous value of a meta row before a local
+// modification, so emergency edits can be rolled back
+type MetaBackup struct {
+	Key   string `orm:"column(key); size(256); pk"`
+	Type  string `orm:"column(type); size(32)"`
+	Value string `orm:"column(value); null; type(text)"`
+	// BackupTime is the RFC3339 time the previous value was saved
+	BackupTime string `orm:"column(backup_time); size(64); null"`
+}
+
+// BackupMetaByKey copies the current meta row into the backup table,
+// replacing any earlier backup of the same key. It is a no-op when the
+// key does not exist
+func BackupMetaByKey(key string) error {
+	if backend != nil {
+		return errBackendUnsupported("BackupMetaByKey")
+	}
+	meta := new([]Meta)
+	if _, err := dbm.DBAccess.QueryTable(MetaTableName).Filter("key", key).All(meta); err != nil {
+		return err
+	}
+	if len(*meta) == 0 {
+		return nil
+	}
+	current := (*meta)[0]
+	_, err := dbm.DBAccess.Raw(dbm.UpsertSQL(BackupTableName, []string{"key", "type", "value", "backup_time"}, "key"),
+		current.Key, current.Type, current.Value, time.Now().UTC().Format(time.RFC3339)).Exec()
+	klog.V(4).Infof("Backup result %v", err)
+	return err
+}
+
+// QueryBackup returns the saved backup of the key, nil when none exists
+func QueryBackup(key string) (*MetaBackup, error) {
+	if backend != nil {
+		return nil, errBackendUnsupported("QueryBackup")
+	}
+	backup := new([]MetaBackup)
+	if _, err := dbm.DBAccess.QueryTable(BackupTableName).Filter("key", key).All(backup); err != nil {
+		return nil, err
+	}
+	if len(*backup) == 0 {
+		return nil, nil
+	}
+	return &(*backup)[0], nil
+}
+
+// QueryAllBackups returns every saved backup row
+func QueryAllBackups() (*[]MetaBackup, error) {
+	if backend != nil {
+		return nil, errBackendUnsupported("QueryAllBackups")
+	}
+	backup := new([]MetaBackup)
+	if _, err := dbm.DBAccess.QueryTable(BackupTableName).All(backup); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// RestoreMetaByKey swaps the saved backup back into the meta table while
+// backing up the value it replaces, so a second rollback undoes the first
+func RestoreMetaByKey(key string) error {
+	backup, err := QueryBackup(key)
+	if err != nil {
+		return err
+	}
+	if backup == nil {
+		return ErrNoBackup
+	}
+	if err := BackupMetaByKey(key); err != nil {
+		return err
+	}
+	return InsertOrUpdate(&Meta{Key: backup.Key, Type: backup.Type, Value: backup.Value})
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+
+	"k8s.io/klog"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// compressedValuePrefix marks a compressed value; plain JSON can never
+// start with it, so reads always know whether to decompress
+const compressedValuePrefix = "gzip+b64:"
+
+// compressMinBytes keeps small rows uncompressed, below it the gzip and
+// base64 framing costs more than it saves
+const compressMinBytes = 1024
+
+// valueCompression is set from the database configuration at startup;
+// decompression on read works regardless so mixed databases stay readable
+var valueCompression bool
+
+// SetValueCompression turns on transparent compression of values written
+// to the meta table, large configmaps shrink to a fraction on disk
+func SetValueCompression(enabled bool) {
+	valueCompression = enabled
+}
+
+// encodeValue compresses a value on its way into the database when
+// compression is enabled and worthwhile
+func encodeValue(value string) string {
+	if !valueCompression || len(value) < compressMinBytes || strings.HasPrefix(value, compressedValuePrefix) {
+		return value
+	}
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write([]byte(value)); err != nil {
+		klog.Errorf("failed to compress value: %v", err)
+		return value
+	}
+	if err := writer.Close(); err != nil {
+		klog.Errorf("failed to compress value: %v", err)
+		return value
+	}
+	encoded := compressedValuePrefix + base64.StdEncoding.EncodeToString(buffer.Bytes())
+	if len(encoded) >= len(value) {
+		// incompressible content, e.g. secrets holding random data
+		return value
+	}
+	return encoded
+}
+
+// DecodeValue reverses encodeValue, values without the marker pass
+// through untouched. It is exported for tools like keadm debug that read
+// the database without going through the query helpers
+func DecodeValue(value string) (string, error) {
+	if !strings.HasPrefix(value, compressedValuePrefix) {
+		return value, nil
+	}
+	compressed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, compressedValuePrefix))
+	if err != nil {
+		return "", err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	decoded, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// decodeMetas decompresses the values of queried rows in place, a row
+// that fails to decode is passed through raw so one corrupt value cannot
+// hide the rest of the listing
+func decodeMetas(metas *[]Meta) {
+	for i := range *metas {
+		decoded, err := DecodeValue((*metas)[i].Value)
+		if err != nil {
+			klog.Errorf("failed to decompress value of %s: %v", (*metas)[i].Key, err)
+			continue
+		}
+		(*metas)[i].Value = decoded
+	}
+}
+
+// CompressExistingRows rewrites every stored row through the current
+// compression setting, migrating databases written before compression was
+// turned on. It returns how many rows were rewritten
+func CompressExistingRows() (int, error) {
+	metas := new([]Meta)
+	if _, err := dbm.DBAccess.QueryTable(MetaTableName).All(metas); err != nil {
+		return 0, err
+	}
+	rewritten := 0
+	for i := range *metas {
+		meta := &(*metas)[i]
+		encoded := encodeValue(meta.Value)
+		if encoded == meta.Value {
+			continue
+		}
+		if err := UpdateMetaField(meta.Key, "value", encoded); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
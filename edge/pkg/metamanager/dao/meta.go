@@ -1,8 +1,12 @@
 package dao
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/astaxie/beego/orm"
 	"k8s.io/klog"
 
 	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
@@ -13,16 +17,48 @@ const (
 	MetaTableName = "meta"
 )
 
+// metaColumns are the columns written by the upsert statements
+var metaColumns = []string{"key", "type", "value", "last_sync", "created_at", "updated_at"}
+
 // Meta metadata object
 type Meta struct {
 	// ID    int64  `orm:"pk; auto; column(id)"`
 	Key   string `orm:"column(key); size(256); pk"`
 	Type  string `orm:"column(type); size(32)"`
 	Value string `orm:"column(value); null; type(text)"`
+	// LastSync is the RFC3339 time the row was last written by a sync,
+	// used by keadm debug to spot stale objects after a disconnection
+	LastSync string `orm:"column(last_sync); size(64); null"`
+	// CreatedAt is the RFC3339 time the row was first written, preserved
+	// across updates
+	CreatedAt string `orm:"column(created_at); size(64); null"`
+	// UpdatedAt is the RFC3339 time the row was last written
+	UpdatedAt string `orm:"column(updated_at); size(64); null"`
+}
+
+// stampLastSync records the write time on the meta row
+func stampLastSync(meta *Meta) {
+	meta.LastSync = time.Now().UTC().Format(time.RFC3339)
+}
+
+// prepareMeta applies the write path transformations every insert and
+// update goes through: the timestamps and value compression. CreatedAt
+// only applies to fresh rows, the upserts preserve the stored value
+func prepareMeta(meta *Meta) {
+	stampLastSync(meta)
+	meta.UpdatedAt = meta.LastSync
+	if meta.CreatedAt == "" {
+		meta.CreatedAt = meta.LastSync
+	}
+	meta.Value = encodeValue(meta.Value)
 }
 
 // SaveMeta save meta to db
 func SaveMeta(meta *Meta) error {
+	if backend != nil {
+		return backendPut(meta)
+	}
+	prepareMeta(meta)
 	num, err := dbm.DBAccess.Insert(meta)
 	klog.V(4).Infof("Insert affected Num: %d, %v", num, err)
 	if err == nil || IsNonUniqueNameError(err) {
@@ -43,27 +79,98 @@ func IsNonUniqueNameError(err error) bool {
 
 // DeleteMetaByKey delete meta by key
 func DeleteMetaByKey(key string) error {
+	if backend != nil {
+		return backend.Delete(key)
+	}
 	num, err := dbm.DBAccess.QueryTable(MetaTableName).Filter("key", key).Delete()
 	klog.V(4).Infof("Delete affected Num: %d, %v", num, err)
 	return err
 }
 
+// DeleteMetaByKeys deletes a batch of rows in one transaction, so a crash
+// cannot leave the table partially cleaned
+func DeleteMetaByKeys(keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if backend != nil {
+		for _, key := range keys {
+			if err := backend.Delete(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return dbm.RunInTransaction(func(ormer orm.Ormer) error {
+		for _, key := range keys {
+			num, err := ormer.QueryTable(MetaTableName).Filter("key", key).Delete()
+			if err != nil {
+				return err
+			}
+			klog.V(4).Infof("Delete affected Num: %d", num)
+		}
+		return nil
+	})
+}
+
 // UpdateMeta update meta
 func UpdateMeta(meta *Meta) error {
-	num, err := dbm.DBAccess.Update(meta) // will update all field
+	if backend != nil {
+		return backendPut(meta)
+	}
+	prepareMeta(meta)
+	// created_at is write-once, everything else is refreshed
+	num, err := dbm.DBAccess.Update(meta, "type", "value", "last_sync", "updated_at")
 	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
 	return err
 }
 
 // InsertOrUpdate insert or update meta
 func InsertOrUpdate(meta *Meta) error {
-	_, err := dbm.DBAccess.Raw("INSERT OR REPLACE INTO meta (key, type, value) VALUES (?,?,?)", meta.Key, meta.Type, meta.Value).Exec() // will update all field
+	if backend != nil {
+		return backendPut(meta)
+	}
+	prepareMeta(meta)
+	_, err := dbm.DBAccess.Raw(dbm.UpsertPreservingSQL(MetaTableName, metaColumns, "key", "created_at"),
+		meta.Key, meta.Type, meta.Value, meta.LastSync, meta.CreatedAt, meta.UpdatedAt).Exec() // will update all field
 	klog.V(4).Infof("Update result %v", err)
 	return err
 }
 
+// BulkInsertOrUpdate writes a batch of rows in one transaction; the initial
+// full sync pushes thousands of objects and row-at-a-time writes are far
+// too slow on flash backed storage
+func BulkInsertOrUpdate(metas []Meta) error {
+	if len(metas) == 0 {
+		return nil
+	}
+	if backend != nil {
+		for i := range metas {
+			if err := backendPut(&metas[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return dbm.RunInTransaction(func(ormer orm.Ormer) error {
+		upsert := dbm.UpsertPreservingSQL(MetaTableName, metaColumns, "key", "created_at")
+		for i := range metas {
+			prepareMeta(&metas[i])
+			if _, err := ormer.Raw(upsert,
+				metas[i].Key, metas[i].Type, metas[i].Value, metas[i].LastSync,
+				metas[i].CreatedAt, metas[i].UpdatedAt).Exec(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // UpdateMetaField update special field
 func UpdateMetaField(key string, col string, value interface{}) error {
+	if backend != nil {
+		return errBackendUnsupported("UpdateMetaField")
+	}
 	num, err := dbm.DBAccess.QueryTable(MetaTableName).Filter("key", key).Update(map[string]interface{}{col: value})
 	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
 	return err
@@ -71,6 +178,9 @@ func UpdateMetaField(key string, col string, value interface{}) error {
 
 // UpdateMetaFields update special fields
 func UpdateMetaFields(key string, cols map[string]interface{}) error {
+	if backend != nil {
+		return errBackendUnsupported("UpdateMetaFields")
+	}
 	num, err := dbm.DBAccess.QueryTable(MetaTableName).Filter("key", key).Update(cols)
 	klog.V(4).Infof("Update affected Num: %d, %v", num, err)
 	return err
@@ -78,12 +188,24 @@ func UpdateMetaFields(key string, cols map[string]interface{}) error {
 
 // QueryMeta return only meta's value, if no error, Meta not null
 func QueryMeta(key string, condition string) (*[]string, error) {
+	if backend != nil {
+		metas, err := backendQuery(key, condition)
+		if err != nil {
+			return nil, err
+		}
+		var result []string
+		for _, v := range *metas {
+			result = append(result, v.Value)
+		}
+		return &result, nil
+	}
 	meta := new([]Meta)
 	_, err := dbm.DBAccess.QueryTable(MetaTableName).Filter(key, condition).All(meta)
 	if err != nil {
 		return nil, err
 	}
 
+	decodeMetas(meta)
 	var result []string
 	for _, v := range *meta {
 		result = append(result, v.Value)
@@ -91,13 +213,172 @@ func QueryMeta(key string, condition string) (*[]string, error) {
 	return &result, nil
 }
 
+// QueryMetaPage returns one page of rows of the given type ordered by key,
+// optionally restricted to a namespace. limit <= 0 returns everything from
+// offset on, so large listings can be fetched incrementally instead of
+// materializing the full slice
+func QueryMetaPage(resType, namespace string, limit, offset int64) (*[]Meta, error) {
+	if backend != nil {
+		metas, err := backendQuery("type", resType)
+		if err != nil {
+			return nil, err
+		}
+		page := *metas
+		if namespace != "" {
+			matched := page[:0]
+			for _, meta := range page {
+				if strings.HasPrefix(meta.Key, namespace+"/") {
+					matched = append(matched, meta)
+				}
+			}
+			page = matched
+		}
+		if offset > 0 {
+			if offset > int64(len(page)) {
+				offset = int64(len(page))
+			}
+			page = page[offset:]
+		}
+		if limit > 0 && limit < int64(len(page)) {
+			page = page[:limit]
+		}
+		return &page, nil
+	}
+	qs := dbm.DBAccess.QueryTable(MetaTableName).Filter("type", resType)
+	if namespace != "" {
+		qs = qs.Filter("key__startswith", namespace+"/")
+	}
+	qs = qs.OrderBy("key")
+	if limit > 0 {
+		qs = qs.Limit(limit, offset)
+	} else if offset > 0 {
+		qs = qs.Offset(offset)
+	}
+
+	meta := new([]Meta)
+	_, err := qs.All(meta)
+	if err != nil {
+		return nil, err
+	}
+	decodeMetas(meta)
+	return meta, nil
+}
+
+// SizeOfMeta returns the summed value size in bytes of the rows the type
+// and optional namespace select, so callers can estimate the memory a
+// listing needs before fetching anything
+func SizeOfMeta(resType, namespace string) (int64, error) {
+	if backend != nil {
+		metas, err := backendQuery("type", resType)
+		if err != nil {
+			return 0, err
+		}
+		var size int64
+		for _, meta := range *metas {
+			if namespace != "" && !strings.HasPrefix(meta.Key, namespace+"/") {
+				continue
+			}
+			size += int64(len(meta.Value))
+		}
+		return size, nil
+	}
+	sql := fmt.Sprintf("SELECT COALESCE(SUM(LENGTH(value)), 0) FROM %s WHERE type = ?", MetaTableName)
+	args := []interface{}{resType}
+	if namespace != "" {
+		sql += " AND key LIKE ?"
+		args = append(args, namespace+"/%")
+	}
+	var size int64
+	err := dbm.DBAccess.Raw(sql, args...).QueryRow(&size)
+	return size, err
+}
+
+// BackfillTimestamps fills the created_at/updated_at columns of rows
+// written before the columns existed, preferring the creationTimestamp
+// stored in the object itself. It returns how many rows were rewritten
+func BackfillTimestamps() (int, error) {
+	if backend != nil {
+		return 0, errBackendUnsupported("BackfillTimestamps")
+	}
+	metas := new([]Meta)
+	if _, err := dbm.DBAccess.QueryTable(MetaTableName).All(metas); err != nil {
+		return 0, err
+	}
+	decodeMetas(metas)
+	rewritten := 0
+	for i := range *metas {
+		meta := &(*metas)[i]
+		if meta.CreatedAt != "" {
+			continue
+		}
+		created := creationTimestampOf(meta.Value)
+		if created == "" {
+			created = meta.LastSync
+		}
+		if created == "" {
+			created = time.Now().UTC().Format(time.RFC3339)
+		}
+		updated := meta.UpdatedAt
+		if updated == "" {
+			updated = meta.LastSync
+		}
+		if err := UpdateMetaFields(meta.Key, map[string]interface{}{
+			"created_at": created,
+			"updated_at": updated,
+		}); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
+
+// creationTimestampOf extracts metadata.creationTimestamp of a stored
+// object, empty when the value holds none
+func creationTimestampOf(value string) string {
+	var partial struct {
+		Metadata struct {
+			CreationTimestamp string `json:"creationTimestamp"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(value), &partial); err != nil {
+		return ""
+	}
+	return partial.Metadata.CreationTimestamp
+}
+
+// QueryMetaKeyTypes returns only the key and type columns of every row,
+// for aggregations over the whole table that never look at the values
+func QueryMetaKeyTypes() (*[]Meta, error) {
+	if backend != nil {
+		metas, err := backendQuery("type", "")
+		if err != nil {
+			return nil, err
+		}
+		for i := range *metas {
+			(*metas)[i].Value = ""
+		}
+		return metas, nil
+	}
+	meta := new([]Meta)
+	_, err := dbm.DBAccess.QueryTable(MetaTableName).All(meta, "Key", "Type")
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
 // QueryAllMeta return all meta, if no error, Meta not null
 func QueryAllMeta(key string, condition string) (*[]Meta, error) {
+	if backend != nil {
+		return backendQuery(key, condition)
+	}
 	meta := new([]Meta)
 	_, err := dbm.DBAccess.QueryTable(MetaTableName).Filter(key, condition).All(meta)
 	if err != nil {
 		return nil, err
 	}
 
+	decodeMetas(meta)
 	return meta, nil
 }
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dao
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/astaxie/beego/orm"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+)
+
+// registerTestModels guards the model registration, beego refuses to
+// register models again once the first database has been bootstrapped
+var registerTestModels sync.Once
+
+// setupMemoryDB points the dao at a fresh private in-memory database
+func setupMemoryDB() {
+	registerTestModels.Do(func() {
+		orm.RegisterModel(new(Meta))
+		orm.RegisterModel(new(MetaBackup))
+	})
+	dbm.InitMemoryDB()
+}
+
+// TestMetaRoundTripInMemoryDB exercises the dao against a real in-memory
+// database instead of mocks, covering the SQL that the orm mocks cannot
+func TestMetaRoundTripInMemoryDB(t *testing.T) {
+	setupMemoryDB()
+
+	meta := &Meta{Key: "default/pod/nginx", Type: "pod", Value: `{"metadata":{"name":"nginx"}}`}
+	if err := InsertOrUpdate(meta); err != nil {
+		t.Fatalf("InsertOrUpdate failed: %v", err)
+	}
+
+	values, err := QueryMeta("key", "default/pod/nginx")
+	if err != nil {
+		t.Fatalf("QueryMeta failed: %v", err)
+	}
+	if len(*values) != 1 || (*values)[0] != meta.Value {
+		t.Fatalf("QueryMeta returned %v, want value %q", *values, meta.Value)
+	}
+
+	if err := BulkInsertOrUpdate([]Meta{
+		{Key: "default/pod/a", Type: "pod", Value: "{}"},
+		{Key: "kube-system/pod/b", Type: "pod", Value: "{}"},
+	}); err != nil {
+		t.Fatalf("BulkInsertOrUpdate failed: %v", err)
+	}
+
+	page, err := QueryMetaPage("pod", "default", 1, 0)
+	if err != nil {
+		t.Fatalf("QueryMetaPage failed: %v", err)
+	}
+	if len(*page) != 1 || (*page)[0].Key != "default/pod/a" {
+		t.Fatalf("QueryMetaPage returned %v, want default/pod/a", *page)
+	}
+
+	if err := BackupMetaByKey("default/pod/nginx"); err != nil {
+		t.Fatalf("BackupMetaByKey failed: %v", err)
+	}
+	if err := InsertOrUpdate(&Meta{Key: "default/pod/nginx", Type: "pod", Value: "{}"}); err != nil {
+		t.Fatalf("InsertOrUpdate of edited value failed: %v", err)
+	}
+	if err := RestoreMetaByKey("default/pod/nginx"); err != nil {
+		t.Fatalf("RestoreMetaByKey failed: %v", err)
+	}
+	values, err = QueryMeta("key", "default/pod/nginx")
+	if err != nil {
+		t.Fatalf("QueryMeta after rollback failed: %v", err)
+	}
+	if len(*values) != 1 || (*values)[0] != meta.Value {
+		t.Fatalf("rollback restored %v, want %q", *values, meta.Value)
+	}
+
+	if err := DeleteMetaByKeys([]string{"default/pod/a", "kube-system/pod/b"}); err != nil {
+		t.Fatalf("DeleteMetaByKeys failed: %v", err)
+	}
+	remaining, err := QueryAllMeta("type", "pod")
+	if err != nil {
+		t.Fatalf("QueryAllMeta failed: %v", err)
+	}
+	if len(*remaining) != 1 {
+		t.Fatalf("expected a single remaining pod row, got %v", *remaining)
+	}
+}
+
+// TestValueCompressionRoundTrip verifies large values written with
+// compression enabled come back decompressed through every query helper
+// and that existing rows can be migrated in place
+func TestValueCompressionRoundTrip(t *testing.T) {
+	setupMemoryDB()
+	defer SetValueCompression(false)
+
+	// highly repetitive, like the configmaps that bloat real databases
+	large := `{"data":{"blob":"` + strings.Repeat("kubeedge ", 1024) + `"}}`
+	if err := InsertOrUpdate(&Meta{Key: "default/configmap/big", Type: "configmap", Value: large}); err != nil {
+		t.Fatalf("InsertOrUpdate before compression failed: %v", err)
+	}
+
+	SetValueCompression(true)
+	rewritten, err := CompressExistingRows()
+	if err != nil {
+		t.Fatalf("CompressExistingRows failed: %v", err)
+	}
+	if rewritten != 1 {
+		t.Fatalf("CompressExistingRows rewrote %d rows, want 1", rewritten)
+	}
+
+	values, err := QueryMeta("key", "default/configmap/big")
+	if err != nil {
+		t.Fatalf("QueryMeta failed: %v", err)
+	}
+	if len(*values) != 1 || (*values)[0] != large {
+		t.Fatalf("QueryMeta did not return the original value")
+	}
+
+	if err := InsertOrUpdate(&Meta{Key: "default/configmap/big2", Type: "configmap", Value: large}); err != nil {
+		t.Fatalf("InsertOrUpdate with compression failed: %v", err)
+	}
+	metas, err := QueryAllMeta("key", "default/configmap/big2")
+	if err != nil {
+		t.Fatalf("QueryAllMeta failed: %v", err)
+	}
+	if len(*metas) != 1 || (*metas)[0].Value != large {
+		t.Fatalf("QueryAllMeta did not return the original value")
+	}
+
+	size, err := SizeOfMeta("configmap", "default")
+	if err != nil {
+		t.Fatalf("SizeOfMeta failed: %v", err)
+	}
+	if size >= 2*int64(len(large)) {
+		t.Fatalf("stored size %d suggests the rows were not compressed", size)
+	}
+}
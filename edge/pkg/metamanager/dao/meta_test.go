@@ -485,3 +485,61 @@ func TestIsNonUniqueNameError(t *testing.T) {
 		})
 	}
 }
+
+// TestQueryMetaPage is function to test QueryMetaPage
+func TestQueryMetaPage(t *testing.T) {
+	//Initialize Global Variables (Mocks)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	ormerMock := beego.NewMockOrmer(mockCtrl)
+	querySeterMock := beego.NewMockQuerySeter(mockCtrl)
+	dbm.DBAccess = ormerMock
+
+	cases := []struct {
+		// name is name of the testcase
+		name string
+		// allReturnInt is the first return of mock interface querySeterMock's all function
+		allReturnInt int64
+		// allReturnErr is the second return of mock interface querySeterMocks's all function also expected error
+		allReturnErr error
+	}{{
+		// Success Case
+		name:         "SuccessCase",
+		allReturnInt: int64(1),
+		allReturnErr: nil,
+	}, {
+		// Failure Case
+		name:         "FailureCase",
+		allReturnInt: int64(0),
+		allReturnErr: errFailedDBOperation,
+	},
+	}
+
+	// fakeDao is used to set the argument of All function
+	fakeDao := new([]Meta)
+	fakeDaoArray := make([]Meta, 1)
+	fakeDaoArray[0] = Meta{Key: "default/pod/test"}
+	fakeDao = &fakeDaoArray
+
+	// run the test cases
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			querySeterMock.EXPECT().All(gomock.Any()).SetArg(0, *fakeDao).Return(test.allReturnInt, test.allReturnErr).Times(1)
+			querySeterMock.EXPECT().Filter(gomock.Any(), gomock.Any()).Return(querySeterMock).Times(2)
+			querySeterMock.EXPECT().OrderBy(gomock.Any()).Return(querySeterMock).Times(1)
+			querySeterMock.EXPECT().Limit(gomock.Any(), gomock.Any()).Return(querySeterMock).Times(1)
+			ormerMock.EXPECT().QueryTable(gomock.Any()).Return(querySeterMock).Times(1)
+			meta, err := QueryMetaPage("pod", "default", 10, 0)
+			if test.allReturnErr != err {
+				t.Errorf("Query Meta Page Case Failed : wanted error %v and got error %v", test.allReturnErr, err)
+				return
+			}
+
+			if err == nil {
+				if len(*meta) != 1 {
+					t.Errorf("Query Meta Page Case failed: wanted length 1 and got length %v", len(*meta))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// +build kvstore_badger
+
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvstore
+
+import (
+	badger "github.com/dgraph-io/badger/v2"
+)
+
+// badgerPath is where the embedded store keeps its value log and tables
+const badgerPath = "/var/lib/kubeedge/edgecore.badger"
+
+func init() {
+	Register("badger", func() (Store, error) {
+		db, err := badger.Open(badger.DefaultOptions(badgerPath))
+		if err != nil {
+			return nil, err
+		}
+		return &badgerStore{db: db}, nil
+	})
+}
+
+// badgerStore keeps entries keyed like the meta table in a badger value
+// log, trading SQL queries for a smaller footprint on tiny devices
+type badgerStore struct {
+	Notifier
+	db *badger.DB
+}
+
+func (s *badgerStore) Get(key string) (*Entry, error) {
+	var entry *Entry
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(value []byte) error {
+			entry = &Entry{Key: key, Type: TypeOfKey(key), Value: string(value)}
+			return nil
+		})
+	})
+	return entry, err
+}
+
+func (s *badgerStore) Put(entry *Entry) error {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte(entry.Key), []byte(entry.Value))
+	})
+	if err != nil {
+		return err
+	}
+	s.Notify(Event{Op: EventPut, Entry: *entry})
+	return nil
+}
+
+func (s *badgerStore) Delete(key string) error {
+	err := s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	})
+	if err != nil {
+		return err
+	}
+	s.Notify(Event{Op: EventDelete, Entry: Entry{Key: key}})
+	return nil
+}
+
+func (s *badgerStore) List(resType string) ([]Entry, error) {
+	var entries []Entry
+	err := s.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key := string(item.Key())
+			entryType := TypeOfKey(key)
+			if resType != "" && entryType != resType {
+				continue
+			}
+			if err := item.Value(func(value []byte) error {
+				entries = append(entries, Entry{Key: key, Type: entryType, Value: string(value)})
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
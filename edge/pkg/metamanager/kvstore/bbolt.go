@@ -0,0 +1,100 @@
+// +build kvstore_bbolt
+
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kvstore
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+// bboltPath is where the embedded store keeps its single database file
+const bboltPath = "/var/lib/kubeedge/edgecore.bolt"
+
+var bboltBucket = []byte("meta")
+
+func init() {
+	Register("bbolt", func() (Store, error) {
+		db, err := bolt.Open(bboltPath, 0600, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(bboltBucket)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+		return &bboltStore{db: db}, nil
+	})
+}
+
+// bboltStore keeps entries in a single bucket keyed like the meta table,
+// a far lighter dependency than ORM plus CGO SQLite on tiny devices
+type bboltStore struct {
+	Notifier
+	db *bolt.DB
+}
+
+func (s *bboltStore) Get(key string) (*Entry, error) {
+	var entry *Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(bboltBucket).Get([]byte(key))
+		if value != nil {
+			entry = &Entry{Key: key, Type: TypeOfKey(key), Value: string(value)}
+		}
+		return nil
+	})
+	return entry, err
+}
+
+func (s *bboltStore) Put(entry *Entry) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bboltBucket).Put([]byte(entry.Key), []byte(entry.Value))
+	})
+	if err != nil {
+		return err
+	}
+	s.Notify(Event{Op: EventPut, Entry: *entry})
+	return nil
+}
+
+func (s *bboltStore) Delete(key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bboltBucket).Delete([]byte(key))
+	})
+	if err != nil {
+		return err
+	}
+	s.Notify(Event{Op: EventDelete, Entry: Entry{Key: key}})
+	return nil
+}
+
+func (s *bboltStore) List(resType string) ([]Entry, error) {
+	var entries []Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bboltBucket).ForEach(func(key, value []byte) error {
+			entryType := TypeOfKey(string(key))
+			if resType != "" && entryType != resType {
+				return nil
+			}
+			entries = append(entries, Entry{Key: string(key), Type: entryType, Value: string(value)})
+			return nil
+		})
+	})
+	return entries, err
+}
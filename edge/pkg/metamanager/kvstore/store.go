@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kvstore defines the key-value storage interface the meta dao
+// routes through when a non-relational backend is selected. The ORM
+// backed SQLite store is the default; embedded backends better suited to
+// microcontroller-class devices (bbolt, badger) plug in behind build
+// tags and the metaManager.storageBackend config setting.
+package kvstore
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Entry is one stored object
+type Entry struct {
+	// Key has the form namespace/type/name
+	Key string
+	// Type is the resource type segment of the key
+	Type string
+	// Value is the JSON encoded object
+	Value string
+}
+
+// EventOp tells what happened to an entry
+type EventOp string
+
+// operations reported on watch channels
+const (
+	EventPut    EventOp = "put"
+	EventDelete EventOp = "delete"
+)
+
+// Event is one change notification delivered to watchers
+type Event struct {
+	Op    EventOp
+	Entry Entry
+}
+
+// Store is the storage interface the meta dao programs against
+type Store interface {
+	// Get returns the entry of the key, nil when it does not exist
+	Get(key string) (*Entry, error)
+	// Put inserts the entry or replaces an existing one
+	Put(entry *Entry) error
+	// Delete removes the key, deleting a missing key is not an error
+	Delete(key string) error
+	// List returns all entries of the resource type, every type when empty
+	List(resType string) ([]Entry, error)
+	// Watch delivers change events for keys with the given prefix until
+	// the returned cancel function is called
+	Watch(prefix string) (<-chan Event, func())
+}
+
+// TypeOfKey extracts the type segment of a namespace/type/name key
+func TypeOfKey(key string) string {
+	tokens := strings.Split(key, "/")
+	if len(tokens) < 2 {
+		return ""
+	}
+	return tokens[1]
+}
+
+// watcher is one active Watch registration
+type watcher struct {
+	prefix string
+	events chan Event
+}
+
+// Notifier implements the Watch half of a Store for backends without
+// native change notifications: backends embed it and call Notify after
+// each successful mutation. The zero value is ready to use
+type Notifier struct {
+	lock        sync.Mutex
+	watchers    map[int]*watcher
+	nextWatchID int
+}
+
+// Watch registers a prefix watcher, satisfying the Store interface for
+// the embedding backend
+func (n *Notifier) Watch(prefix string) (<-chan Event, func()) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.watchers == nil {
+		n.watchers = map[int]*watcher{}
+	}
+	id := n.nextWatchID
+	n.nextWatchID++
+	w := &watcher{prefix: prefix, events: make(chan Event, 64)}
+	n.watchers[id] = w
+	cancel := func() {
+		n.lock.Lock()
+		defer n.lock.Unlock()
+		if _, ok := n.watchers[id]; ok {
+			delete(n.watchers, id)
+			close(w.events)
+		}
+	}
+	return w.events, cancel
+}
+
+// Notify fans the event out to the watchers whose prefix matches, slow
+// watchers lose events rather than stall the store
+func (n *Notifier) Notify(event Event) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	for _, w := range n.watchers {
+		if !strings.HasPrefix(event.Entry.Key, w.prefix) {
+			continue
+		}
+		select {
+		case w.events <- event:
+		default:
+		}
+	}
+}
+
+// Provider creates a Store, invoked once when the backend is selected
+type Provider func() (Store, error)
+
+var (
+	providersLock sync.Mutex
+	providers     = map[string]Provider{}
+)
+
+// Register makes a storage backend selectable by name, it is expected to
+// be called from init functions of the backend files
+func Register(name string, provider Provider) {
+	providersLock.Lock()
+	defer providersLock.Unlock()
+	providers[name] = provider
+}
+
+// New creates the store of the named backend
+func New(name string) (Store, error) {
+	providersLock.Lock()
+	provider, ok := providers[name]
+	providersLock.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("storage backend %s is not compiled in, rebuild with the matching kvstore build tag", name)
+	}
+	return provider()
+}
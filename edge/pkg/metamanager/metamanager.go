@@ -22,6 +22,10 @@ const (
 
 type metaManager struct {
 	enable bool
+	// cordoned tells whether applying cloud updates is paused; it and the
+	// buffer are only touched from the main message loop
+	cordoned     bool
+	cordonBuffer []model.Message
 }
 
 func newMetaManager(enable bool) *metaManager {
@@ -44,6 +48,7 @@ func initDBTable(module core.Module) {
 		return
 	}
 	orm.RegisterModel(new(dao.Meta))
+	orm.RegisterModel(new(dao.MetaBackup))
 }
 
 func (*metaManager) Name() string {
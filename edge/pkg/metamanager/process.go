@@ -14,6 +14,7 @@ import (
 	"github.com/kubeedge/beehive/pkg/core/model"
 	"github.com/kubeedge/kubeedge/common/constants"
 	connect "github.com/kubeedge/kubeedge/edge/pkg/common/cloudconnection"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
 	messagepkg "github.com/kubeedge/kubeedge/edge/pkg/common/message"
 	"github.com/kubeedge/kubeedge/edge/pkg/common/modules"
 	metaManagerConfig "github.com/kubeedge/kubeedge/edge/pkg/metamanager/config"
@@ -31,6 +32,11 @@ const (
 
 	OperationFunctionActionResult = "action_result"
 
+	// OperationCordon and OperationUncordon pause and resume applying
+	// cloud updates, see processCordon
+	OperationCordon   = "cordon"
+	OperationUncordon = "uncordon"
+
 	EdgeFunctionModel   = "edgefunction"
 	CloudFunctionModel  = "funcmgr"
 	CloudControlerModel = "edgecontroller"
@@ -223,6 +229,7 @@ func (m *metaManager) processUpdate(message model.Message) {
 				feedbackError(err, "Error to unmarshal", message)
 				return
 			}
+			metas := make([]dao.Meta, 0, len(epsList))
 			for _, eps := range epsList {
 				data, err := json.Marshal(eps)
 				if err != nil {
@@ -230,15 +237,13 @@ func (m *metaManager) processUpdate(message model.Message) {
 					continue
 				}
 
-				meta := &dao.Meta{
+				metas = append(metas, dao.Meta{
 					Key:   fmt.Sprintf("%s/%s/%s", eps.Namespace, constants.ResourceTypeEndpoints, eps.Name),
 					Type:  constants.ResourceTypeEndpoints,
-					Value: string(data)}
-				err = dao.InsertOrUpdate(meta)
-				if err != nil {
-					klog.Errorf("Update meta failed, %v", eps)
-					continue
-				}
+					Value: string(data)})
+			}
+			if err = dao.BulkInsertOrUpdate(metas); err != nil {
+				klog.Errorf("Update endpoints meta failed: %v", err)
 			}
 			sendToEdgeMesh(&message, false)
 			resp := message.NewRespByMessage(&message, OK)
@@ -252,6 +257,7 @@ func (m *metaManager) processUpdate(message model.Message) {
 				feedbackError(err, "Error to unmarshal", message)
 				return
 			}
+			metas := make([]dao.Meta, 0, len(svcList))
 			for _, svc := range svcList {
 				data, err := json.Marshal(svc)
 				if err != nil {
@@ -259,15 +265,13 @@ func (m *metaManager) processUpdate(message model.Message) {
 					continue
 				}
 
-				meta := &dao.Meta{
+				metas = append(metas, dao.Meta{
 					Key:   fmt.Sprintf("%s/%s/%s", svc.Namespace, constants.ResourceTypeService, svc.Name),
 					Type:  constants.ResourceTypeService,
-					Value: string(data)}
-				err = dao.InsertOrUpdate(meta)
-				if err != nil {
-					klog.Errorf("Update meta failed, %v", svc)
-					continue
-				}
+					Value: string(data)})
+			}
+			if err = dao.BulkInsertOrUpdate(metas); err != nil {
+				klog.Errorf("Update service meta failed: %v", err)
 			}
 			sendToEdgeMesh(&message, false)
 			resp := message.NewRespByMessage(&message, OK)
@@ -523,6 +527,7 @@ func (m *metaManager) syncPodStatus() {
 		return
 	}
 	contents := make(map[string][]interface{})
+	var staleKeys []string
 	for _, v := range *podStatusRecords {
 		namespaceParsed, _, _, _ := util.ParseResourceEdge(v.Key, model.QueryOperation)
 		podKey := strings.Replace(v.Key, constants.ResourceSep+model.ResourceTypePodStatus+constants.ResourceSep, constants.ResourceSep+model.ResourceTypePod+constants.ResourceSep, 1)
@@ -534,8 +539,8 @@ func (m *metaManager) syncPodStatus() {
 
 		if len(*podRecord) <= 0 {
 			// pod already deleted, clear the corresponding podstatus record
-			err = dao.DeleteMetaByKey(v.Key)
-			klog.Infof("pod[%s] already deleted, clear podstatus record, result:%v", podKey, err)
+			klog.Infof("pod[%s] already deleted, clear podstatus record", podKey)
+			staleKeys = append(staleKeys, v.Key)
 			continue
 		}
 
@@ -547,6 +552,9 @@ func (m *metaManager) syncPodStatus() {
 		}
 		contents[namespaceParsed] = append(contents[namespaceParsed], podStatus)
 	}
+	if err := dao.DeleteMetaByKeys(staleKeys); err != nil {
+		klog.Errorf("clear stale podstatus records failed: %v", err)
+	}
 	for namespace, content := range contents {
 		msg := model.NewMessage("").BuildRouter(MetaManagerModuleName, GroupResource, namespace+constants.ResourceSep+model.ResourceTypePodStatus, model.UpdateOperation).FillBody(content)
 		sendToCloud(msg)
@@ -627,9 +635,68 @@ func (m *metaManager) processVolume(message model.Message) {
 	klog.Infof("process volume send to cloud resp[%+v]", resp)
 }
 
+// cordonBufferLimit caps how many cloud updates a cordoned metamanager
+// keeps; beyond it the oldest buffered update is dropped
+const cordonBufferLimit = 4096
+
+// bufferIfCordoned holds back cloud updates while the node is cordoned
+// for live debugging, so the state under investigation cannot change
+// underneath the operator. Local messages keep flowing
+func (m *metaManager) bufferIfCordoned(message *model.Message) bool {
+	if !m.cordoned || message.GetSource() != CloudControlerModel {
+		return false
+	}
+	switch message.GetOperation() {
+	case model.InsertOperation, model.UpdateOperation, model.DeleteOperation:
+	default:
+		return false
+	}
+	if len(m.cordonBuffer) >= cordonBufferLimit {
+		klog.Warningf("cordon buffer full, dropping oldest buffered message %s", msgDebugInfo(&m.cordonBuffer[0]))
+		m.cordonBuffer = m.cordonBuffer[1:]
+	}
+	m.cordonBuffer = append(m.cordonBuffer, *message)
+	klog.Infof("node is cordoned, buffered %s", msgDebugInfo(message))
+	return true
+}
+
+// processCordon pauses applying cloud updates; buffered updates are kept
+// and replayed in order by processUncordon, so nothing from the cloud is
+// lost across a debugging session
+func (m *metaManager) processCordon(message model.Message) {
+	m.cordoned = true
+	if message.IsSync() {
+		beehiveContext.SendResp(*message.NewRespByMessage(&message, OK))
+	}
+}
+
+// processUncordon resumes applying cloud updates and replays what was
+// buffered while cordoned
+func (m *metaManager) processUncordon(message model.Message) {
+	m.cordoned = false
+	buffered := m.cordonBuffer
+	m.cordonBuffer = nil
+	for i := range buffered {
+		m.process(buffered[i])
+	}
+	if message.IsSync() {
+		beehiveContext.SendResp(*message.NewRespByMessage(&message,
+			fmt.Sprintf("replayed %d buffered cloud updates", len(buffered))))
+	}
+}
+
 func (m *metaManager) process(message model.Message) {
+	if m.bufferIfCordoned(&message) {
+		return
+	}
 	operation := message.GetOperation()
 	switch operation {
+	case model.InsertOperation, model.UpdateOperation, model.DeleteOperation:
+		// feed the watchers of keadm debug events before processing, so
+		// they see the operation even when applying it fails
+		debugserver.PublishEvent(operation, message.GetSource(), message.GetResource())
+	}
+	switch operation {
 	case model.InsertOperation:
 		m.processInsert(message)
 	case model.UpdateOperation:
@@ -644,6 +711,10 @@ func (m *metaManager) process(message model.Message) {
 		m.processNodeConnection(message)
 	case OperationMetaSync:
 		m.processSync(message)
+	case OperationCordon:
+		m.processCordon(message)
+	case OperationUncordon:
+		m.processUncordon(message)
 	case OperationFunctionAction:
 		m.processFunctionAction(message)
 	case OperationFunctionActionResult:
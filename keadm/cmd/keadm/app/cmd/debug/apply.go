@@ -0,0 +1,188 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var (
+	applyLongDescription = `
+    Write a manifest into the edge metadata database, the offline
+    equivalent of kubectl apply for emergencies where the cloud is
+    unreachable. The value a manifest replaces is saved first, so
+    "keadm debug rollback" can undo the change.
+
+    The database write alone only updates what the node believes; with
+    --dispatch the corresponding insert or update message is also injected
+    into the running edgecore through its debug socket, so edged acts on
+    the manifest immediately.
+`
+	applyExample = `
+    # Write a pod manifest into the edge database
+    keadm debug apply -f pod.yaml
+
+    # Write it and tell the running edgecore to act on it
+    keadm debug apply -f pod.yaml --dispatch
+`
+)
+
+// ApplyOptions has the apply subcommand information filled by CLI
+type ApplyOptions struct {
+	EdgecoreConfig string
+	Input          string
+	File           string
+	Dispatch       bool
+	Socket         string
+	Timeout        int64
+}
+
+// NewApplyOptions returns apply options with defaults
+func NewApplyOptions() *ApplyOptions {
+	return &ApplyOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+		Socket:         debugserver.DefaultSocket,
+		Timeout:        30,
+	}
+}
+
+// NewApply returns the cobra command writing manifests into the edge database
+func NewApply(out io.Writer) *cobra.Command {
+	opts := NewApplyOptions()
+	cmd := &cobra.Command{
+		Use:     "apply",
+		Short:   "Write a manifest into the edge database",
+		Long:    applyLongDescription,
+		Example: applyExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunApply(out, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.File, "file", "f", opts.File,
+		"Manifest to apply, - reads from stdin; multiple YAML documents are applied in order")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	cmd.Flags().BoolVar(&opts.Dispatch, "dispatch", opts.Dispatch,
+		"Also inject the matching beehive message so the running edgecore acts on the manifest")
+	cmd.Flags().StringVar(&opts.Socket, "socket", opts.Socket,
+		"Path of the edgecore debug socket used by --dispatch")
+	cmd.Flags().Int64Var(&opts.Timeout, "timeout", opts.Timeout,
+		"Seconds to wait for the module response when dispatching")
+	return cmd
+}
+
+// RunApply validates the manifests and upserts them as meta rows
+func RunApply(out io.Writer, opts *ApplyOptions) error {
+	if err := requireOperator("debug apply"); err != nil {
+		return err
+	}
+	if opts.File == "" {
+		return errors.New("a manifest is required, use -f")
+	}
+	var data []byte
+	var err error
+	if opts.File == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(opts.File)
+	}
+	if err != nil {
+		return err
+	}
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+
+	for _, document := range strings.Split(string(data), "\n---") {
+		if strings.TrimSpace(document) == "" {
+			continue
+		}
+		if err := applyManifest(out, opts, []byte(document)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyManifest validates one manifest, backs up the row it replaces,
+// upserts it and optionally dispatches the matching message
+func applyManifest(out io.Writer, opts *ApplyOptions, document []byte) error {
+	jsonData, err := yaml.YAMLToJSON(document)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the manifest")
+	}
+	key := resourceFromManifest(jsonData)
+	if key == "" {
+		return errors.New("the manifest needs kind and metadata.name to be stored")
+	}
+
+	existing, err := dao.QueryAllMeta("key", key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to query %s", key)
+	}
+	operation := model.InsertOperation
+	if len(*existing) > 0 {
+		operation = model.UpdateOperation
+	}
+	// keep the replaced value so the change can be rolled back; a fresh
+	// key has nothing to back up
+	if err := dao.BackupMetaByKey(key); err != nil {
+		return errors.Wrapf(err, "failed to back up %s", key)
+	}
+
+	tokens := strings.Split(key, "/")
+	if err := dao.InsertOrUpdate(&dao.Meta{Key: key, Type: tokens[1], Value: string(jsonData)}); err != nil {
+		return errors.Wrapf(err, "failed to write %s", key)
+	}
+	fmt.Fprintf(out, "%s applied\n", key)
+
+	if !opts.Dispatch {
+		return nil
+	}
+	message := model.NewMessage("").
+		BuildRouter("keadm", "", key, operation).
+		FillBody(string(jsonData))
+	response, err := sendDebugRequest(opts.Socket, &debugserver.Request{
+		Op:             debugserver.OpInject,
+		Module:         "edged",
+		Sync:           true,
+		TimeoutSeconds: opts.Timeout,
+		Message:        message,
+	})
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.Errorf("edged failed to handle %s: %s", key, response.Error)
+	}
+	fmt.Fprintf(out, "%s dispatched to edged\n", key)
+	return nil
+}
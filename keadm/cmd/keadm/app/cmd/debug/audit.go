@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// auditLogPath is the append-only local record of what keadm did on this
+// node: every debug invocation and every secret decryption, one line each
+const auditLogPath = "/var/lib/kubeedge/keadm-audit.log"
+
+// sensitiveFlagWords mark flags whose values never reach the audit log
+var sensitiveFlagWords = []string{"token", "password", "secret", "key"}
+
+var auditLongDescription = `
+    Print the local audit log of keadm. Every debug subcommand run on this
+    node is recorded with user, arguments and result, as is every secret
+    decryption, for sites with change-control obligations on edge devices.
+`
+
+// AuditOptions has the audit subcommand information filled by CLI
+type AuditOptions struct {
+	Tail int
+}
+
+// NewAudit returns the cobra command printing the local audit log
+func NewAudit(out io.Writer) *cobra.Command {
+	opts := &AuditOptions{}
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Print the local audit log of keadm invocations",
+		Long:  auditLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunAudit(out, opts)
+		},
+	}
+	cmd.Flags().IntVar(&opts.Tail, "tail", opts.Tail,
+		"Only print the last N entries, 0 prints everything")
+	return cmd
+}
+
+// RunAudit prints the audit log, optionally only its tail
+func RunAudit(out io.Writer, opts *AuditOptions) error {
+	data, err := ioutil.ReadFile(auditLogPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Fprintf(out, T("No audit log exists at %s yet\n"), auditLogPath)
+			return nil
+		}
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if opts.Tail > 0 && len(lines) > opts.Tail {
+		lines = lines[len(lines)-opts.Tail:]
+	}
+	for _, line := range lines {
+		fmt.Fprintln(out, line)
+	}
+	return nil
+}
+
+// auditWrapCommands wraps every runnable command of the tree so each
+// invocation lands in the audit log with its result
+func auditWrapCommands(cmd *cobra.Command) {
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			err := run(cmd, args)
+			auditInvocation(err)
+			return err
+		}
+	}
+	for _, child := range cmd.Commands() {
+		auditWrapCommands(child)
+	}
+}
+
+// auditInvocation records one finished command. Audit failures are not
+// fatal for read paths, the mutating commands additionally refuse to
+// decrypt secrets without audit logging
+func auditInvocation(result error) {
+	verdict := "ok"
+	if result != nil {
+		verdict = fmt.Sprintf("error=%q", result.Error())
+	}
+	auditAppend(fmt.Sprintf("command=%q result=%s", strings.Join(scrubArgs(os.Args[1:]), " "), verdict))
+}
+
+// scrubArgs redacts the values of credential carrying flags before the
+// arguments are logged
+func scrubArgs(args []string) []string {
+	scrubbed := make([]string, len(args))
+	redactNext := false
+	for i, arg := range args {
+		if redactNext {
+			scrubbed[i] = "REDACTED"
+			redactNext = false
+			continue
+		}
+		scrubbed[i] = arg
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		value := ""
+		if eq := strings.Index(name, "="); eq >= 0 {
+			name, value = name[:eq], name[eq+1:]
+		}
+		for _, word := range sensitiveFlagWords {
+			if !strings.Contains(strings.ToLower(name), word) {
+				continue
+			}
+			if value != "" {
+				scrubbed[i] = strings.SplitN(arg, "=", 2)[0] + "=REDACTED"
+			} else {
+				redactNext = true
+			}
+			break
+		}
+	}
+	return scrubbed
+}
+
+// auditAppend writes one timestamped, attributed line to the audit log
+func auditAppend(entry string) error {
+	username := "unknown"
+	if current, err := user.Current(); err == nil {
+		username = current.Username
+	}
+	if err := os.MkdirAll(filepath.Dir(auditLogPath), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(auditLogPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "%s user=%s %s\n",
+		time.Now().UTC().Format(time.RFC3339), username, entry)
+	return err
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"crypto/subtle"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// operatorGroup is the unix group whose members may run the mutating
+// debug subcommands
+const operatorGroup = "kubeedge-ops"
+
+// operatorTokenPath holds the operator token for installations that
+// prefer a shared token over group membership. The file should be
+// readable by operators only
+const operatorTokenPath = "/etc/kubeedge/operator-token"
+
+// operatorTokenEnv is the environment variable a caller presents a token
+// through
+const operatorTokenEnv = "KEADM_OPERATOR_TOKEN"
+
+// requireOperator gates the subcommands that mutate node state so the
+// read-only listing and diagnostic commands stay usable by field staff
+// while apply, sync, chaos and friends are not. Root, members of
+// operatorGroup and callers presenting the operator token pass
+func requireOperator(action string) error {
+	current, err := user.Current()
+	if err != nil {
+		return errors.Wrap(err, "failed to identify the calling user")
+	}
+	if current.Uid == "0" {
+		return nil
+	}
+	if inOperatorGroup(current) {
+		return nil
+	}
+	if presentsOperatorToken() {
+		return nil
+	}
+	return errors.Errorf("%s mutates node state and needs operator rights: join group %s or present the token of %s via %s",
+		action, operatorGroup, operatorTokenPath, operatorTokenEnv)
+}
+
+// inOperatorGroup tells whether the user is a member of operatorGroup
+func inOperatorGroup(current *user.User) bool {
+	operators, err := user.LookupGroup(operatorGroup)
+	if err != nil {
+		return false
+	}
+	ids, err := current.GroupIds()
+	if err != nil {
+		return false
+	}
+	for _, id := range ids {
+		if id == operators.Gid {
+			return true
+		}
+	}
+	return false
+}
+
+// presentsOperatorToken compares the token from the environment with the
+// one on disk, in constant time so the gate leaks nothing about it
+func presentsOperatorToken() bool {
+	presented := os.Getenv(operatorTokenEnv)
+	if presented == "" {
+		return false
+	}
+	expected, err := ioutil.ReadFile(operatorTokenPath)
+	if err != nil {
+		return false
+	}
+	token := strings.TrimSpace(string(expected))
+	if token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
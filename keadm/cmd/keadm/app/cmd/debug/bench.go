@@ -0,0 +1,228 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var (
+	benchDBLongDescription = `
+    Measure fsync latency, write throughput and row-scan throughput of the
+    storage holding the edge metadata database, and compare the numbers
+    against recommended thresholds. Slow SD cards are a recurring hidden
+    cause of edge flakiness and this makes them visible in one command.
+`
+	benchDBExample = `
+    # Benchmark the storage of the configured database
+    keadm debug bench db
+
+    # Benchmark with more fsync samples for a steadier latency figure
+    keadm debug bench db --fsync-samples 100
+`
+)
+
+// benchmark thresholds, chosen so a healthy eMMC or SSD passes easily and
+// a worn out SD card does not
+const (
+	benchFsyncThreshold = 25 * time.Millisecond
+	benchWriteThreshold = 10.0   // MB/s
+	benchScanThreshold  = 1000.0 // rows/s
+)
+
+// benchWriteBytes is how much data the write throughput probe writes
+const benchWriteBytes = 4 << 20
+
+// BenchDBOptions has the bench db subcommand information filled by CLI
+type BenchDBOptions struct {
+	EdgecoreConfig   string
+	Input            string
+	FsyncSamples     int
+	CheckPermissions bool
+}
+
+// NewBenchDBOptions returns bench db options with defaults
+func NewBenchDBOptions() *BenchDBOptions {
+	return &BenchDBOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+		FsyncSamples:   20,
+	}
+}
+
+// NewBench returns the cobra command grouping the micro-benchmarks
+func NewBench(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Micro-benchmarks for edge node components",
+	}
+	cmd.AddCommand(NewBenchDB(out))
+	return cmd
+}
+
+// NewBenchDB returns the cobra command benchmarking the database storage
+func NewBenchDB(out io.Writer) *cobra.Command {
+	opts := NewBenchDBOptions()
+	cmd := &cobra.Command{
+		Use:     "db",
+		Short:   "Benchmark the storage holding the edge database",
+		Long:    benchDBLongDescription,
+		Example: benchDBExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunBenchDB(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	cmd.Flags().IntVar(&opts.FsyncSamples, "fsync-samples", opts.FsyncSamples,
+		"Number of fsync operations to sample")
+	cmd.Flags().BoolVar(&opts.CheckPermissions, "check-permissions", opts.CheckPermissions,
+		"Only verify access to the paths the command needs and report the results")
+	return cmd
+}
+
+// RunBenchDB measures the storage medium of the database and reports
+// whether it meets the recommended thresholds
+func RunBenchDB(out io.Writer, opts *BenchDBOptions) error {
+	if opts.CheckPermissions {
+		return RunCheckPermissions(out, opts.EdgecoreConfig, opts.Input)
+	}
+	if opts.FsyncSamples <= 0 {
+		return errors.Errorf("--fsync-samples must be positive, got %d", opts.FsyncSamples)
+	}
+	dbPath := opts.Input
+	if dbPath == "" {
+		config, err := LoadEdgecoreConfig(opts.EdgecoreConfig)
+		if err != nil {
+			return err
+		}
+		dbPath = config.DataBase.DataSource
+	}
+	dir := filepath.Dir(dbPath)
+
+	fsyncAvg, fsyncWorst, err := benchFsync(dir, opts.FsyncSamples)
+	if err != nil {
+		return errors.Wrapf(err, "failed to benchmark fsync in %s", dir)
+	}
+	writeMBs, err := benchWrite(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to benchmark writes in %s", dir)
+	}
+	rowsPerSec, rowCount, err := benchScan(opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to benchmark row scan")
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("METRIC\tVALUE\tTHRESHOLD\tRESULT"))
+	fmt.Fprintf(w, "fsync latency (avg)\t%v\t<= %v\t%s\n",
+		fsyncAvg.Round(time.Microsecond), benchFsyncThreshold, benchVerdict(fsyncAvg <= benchFsyncThreshold))
+	fmt.Fprintf(w, "fsync latency (worst)\t%v\t\t\n", fsyncWorst.Round(time.Microsecond))
+	fmt.Fprintf(w, "write throughput\t%.1f MB/s\t>= %.0f MB/s\t%s\n",
+		writeMBs, benchWriteThreshold, benchVerdict(writeMBs >= benchWriteThreshold))
+	fmt.Fprintf(w, "row scan (%d rows)\t%.0f rows/s\t>= %.0f rows/s\t%s\n",
+		rowCount, rowsPerSec, benchScanThreshold, benchVerdict(rowsPerSec >= benchScanThreshold))
+	return w.Flush()
+}
+
+// benchVerdict renders a threshold comparison result
+func benchVerdict(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "SLOW"
+}
+
+// benchFsync samples the latency of small synced writes in dir, the
+// pattern SQLite commits produce, and returns the average and worst case
+func benchFsync(dir string, samples int) (time.Duration, time.Duration, error) {
+	file, err := os.Create(filepath.Join(dir, ".keadm-bench"))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	block := make([]byte, 4096)
+	var total, worst time.Duration
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		if _, err := file.Write(block); err != nil {
+			return 0, 0, err
+		}
+		if err := file.Sync(); err != nil {
+			return 0, 0, err
+		}
+		elapsed := time.Since(start)
+		total += elapsed
+		if elapsed > worst {
+			worst = elapsed
+		}
+	}
+	return total / time.Duration(samples), worst, nil
+}
+
+// benchWrite measures sequential write throughput in dir in MB/s
+func benchWrite(dir string) (float64, error) {
+	file, err := os.Create(filepath.Join(dir, ".keadm-bench"))
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	block := make([]byte, 64<<10)
+	start := time.Now()
+	for written := 0; written < benchWriteBytes; written += len(block) {
+		if _, err := file.Write(block); err != nil {
+			return 0, err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		return 0, err
+	}
+	return float64(benchWriteBytes) / (1 << 20) / time.Since(start).Seconds(), nil
+}
+
+// benchScan measures how fast the meta table can be read end to end
+func benchScan(opts *BenchDBOptions) (float64, int, error) {
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return 0, 0, err
+	}
+	start := time.Now()
+	metas, err := dao.QueryAllMeta("key__contains", "/")
+	if err != nil {
+		return 0, 0, err
+	}
+	elapsed := time.Since(start).Seconds()
+	count := len(*metas)
+	if count == 0 || elapsed == 0 {
+		return 0, count, nil
+	}
+	return float64(count) / elapsed, count, nil
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var chaosLongDescription = `
+    Inject controlled faults on this edge node to validate workload
+    resilience on real hardware: kill a pod's container, fill a disk or
+    add network latency. Every fault cleans up after itself when its
+    duration elapses or the command is interrupted.
+
+    All chaos subcommands require --confirm and take a lock so only one
+    fault runs at a time.
+`
+
+// chaosLockPath is the lock every chaos subcommand holds while a fault is
+// active, so two faults cannot overlap
+const chaosLockPath = "/var/lib/kubeedge/chaos.lock"
+
+// ChaosOptions has the chaos subcommand information shared by all faults
+type ChaosOptions struct {
+	Confirm  bool
+	Duration time.Duration
+}
+
+// addChaosFlags registers the flags shared by the chaos subcommands
+func addChaosFlags(cmd *cobra.Command, opts *ChaosOptions) {
+	cmd.Flags().BoolVar(&opts.Confirm, "confirm", opts.Confirm,
+		"Acknowledge that this injects a real fault on this node")
+	cmd.Flags().DurationVar(&opts.Duration, "duration", opts.Duration,
+		"How long the fault stays active before it is cleaned up")
+}
+
+// NewChaos returns the cobra command grouping the fault injections
+func NewChaos(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "chaos",
+		Short: "Inject controlled faults to test workload resilience",
+		Long:  chaosLongDescription,
+	}
+	cmd.AddCommand(NewChaosKillContainer(out))
+	cmd.AddCommand(NewChaosFillDisk(out))
+	cmd.AddCommand(NewChaosAddLatency(out))
+	return cmd
+}
+
+// acquireChaosLock takes the chaos lock, replacing it when its owner is
+// gone, and returns the release function
+func acquireChaosLock() (func(), error) {
+	if data, err := ioutil.ReadFile(chaosLockPath); err == nil {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && processAlive(pid) {
+			return nil, errors.Errorf("another chaos fault is active (pid %d), wait for it or remove %s", pid, chaosLockPath)
+		}
+		// the owner is gone, the fault crashed without cleanup
+		os.Remove(chaosLockPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(chaosLockPath), os.ModePerm); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(chaosLockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to take the chaos lock %s", chaosLockPath)
+	}
+	fmt.Fprintf(file, "%d\n", os.Getpid())
+	file.Close()
+	return func() { os.Remove(chaosLockPath) }, nil
+}
+
+// processAlive tells whether the pid belongs to a running process
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(nil) == nil
+}
+
+// runChaosFault frames one fault: confirmation, lock, activation, waiting
+// out the duration (or an interrupt) and cleanup
+func runChaosFault(out io.Writer, opts *ChaosOptions, activate func() error, cleanup func() error) error {
+	if err := requireOperator("debug chaos"); err != nil {
+		return err
+	}
+	if !opts.Confirm {
+		return errors.New("this injects a real fault on this node, re-run with --confirm")
+	}
+	release, err := acquireChaosLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := activate(); err != nil {
+		return err
+	}
+	if cleanup == nil {
+		return nil
+	}
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+	select {
+	case <-time.After(opts.Duration):
+	case <-interrupted:
+		fmt.Fprintln(out, "interrupted, cleaning up")
+	}
+	return cleanup()
+}
+
+// NewChaosKillContainer returns the cobra command killing a pod's container
+func NewChaosKillContainer(out io.Writer) *cobra.Command {
+	opts := &ChaosOptions{}
+	var namespace, pod, container string
+	cmd := &cobra.Command{
+		Use:   "kill-container",
+		Short: "Kill a container of a pod to test that it recovers",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pod == "" {
+				return errors.New("--pod is required")
+			}
+			return runChaosFault(out, opts, func() error {
+				return chaosKillContainer(out, namespace, pod, container)
+			}, nil)
+		},
+	}
+	addChaosFlags(cmd, opts)
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", "default",
+		"Namespace of the pod")
+	cmd.Flags().StringVar(&pod, "pod", pod,
+		"Name of the pod whose container is killed")
+	cmd.Flags().StringVar(&container, "container", container,
+		"Name of the container, every container of the pod when empty")
+	return cmd
+}
+
+// chaosKillContainer kills the matching containers through the container
+// runtime; edged restarts them according to the pod's restart policy
+func chaosKillContainer(out io.Writer, namespace, pod, container string) error {
+	filters := []string{
+		"--filter", "label=io.kubernetes.pod.namespace=" + namespace,
+		"--filter", "label=io.kubernetes.pod.name=" + pod,
+	}
+	if container != "" {
+		filters = append(filters, "--filter", "label=io.kubernetes.container.name="+container)
+	}
+	listing, err := exec.Command("docker", append([]string{"ps", "-q"}, filters...)...).Output()
+	if err != nil {
+		return errors.Wrap(err, "failed to list containers through docker")
+	}
+	ids := strings.Fields(string(listing))
+	if len(ids) == 0 {
+		return errors.Errorf("no running container found for pod %s/%s", namespace, pod)
+	}
+	for _, id := range ids {
+		if output, err := exec.Command("docker", "kill", id).CombinedOutput(); err != nil {
+			return errors.Errorf("failed to kill container %s: %s", id, string(output))
+		}
+		fmt.Fprintf(out, "killed container %s of pod %s/%s\n", id, namespace, pod)
+	}
+	return nil
+}
+
+// NewChaosFillDisk returns the cobra command filling a disk
+func NewChaosFillDisk(out io.Writer) *cobra.Command {
+	opts := &ChaosOptions{Duration: 5 * time.Minute}
+	var path, size string
+	cmd := &cobra.Command{
+		Use:   "fill-disk",
+		Short: "Fill disk space for a duration to test pressure handling",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			quantity, err := resource.ParseQuantity(size)
+			if err != nil {
+				return errors.Wrapf(err, "invalid --size value %s", size)
+			}
+			ballast := filepath.Join(path, ".keadm-chaos-ballast")
+			return runChaosFault(out, opts, func() error {
+				fmt.Fprintf(out, "filling %s of %s for %v\n", size, path, opts.Duration)
+				return fillFile(ballast, quantity.Value())
+			}, func() error {
+				return os.Remove(ballast)
+			})
+		},
+	}
+	addChaosFlags(cmd, opts)
+	cmd.Flags().StringVar(&path, "path", "/var/lib/kubeedge",
+		"Directory whose filesystem is filled")
+	cmd.Flags().StringVar(&size, "size", "1Gi",
+		"How much space the ballast file takes, e.g. 500Mi")
+	return cmd
+}
+
+// fillFile writes a ballast file of the given size
+func fillFile(path string, size int64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	block := make([]byte, 1<<20)
+	for written := int64(0); written < size; written += int64(len(block)) {
+		if remaining := size - written; remaining < int64(len(block)) {
+			block = block[:remaining]
+		}
+		if _, err := file.Write(block); err != nil {
+			// a full disk is the point, stop here and keep the ballast
+			if strings.Contains(err.Error(), "no space left") {
+				return nil
+			}
+			return err
+		}
+	}
+	return file.Sync()
+}
+
+// NewChaosAddLatency returns the cobra command adding network latency
+func NewChaosAddLatency(out io.Writer) *cobra.Command {
+	opts := &ChaosOptions{Duration: time.Minute}
+	var device, latency string
+	cmd := &cobra.Command{
+		Use:   "add-latency",
+		Short: "Add network latency on an interface for a duration",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runChaosFault(out, opts, func() error {
+				fmt.Fprintf(out, "adding %s latency on %s for %v\n", latency, device, opts.Duration)
+				output, err := exec.Command("tc", "qdisc", "add", "dev", device, "root", "netem", "delay", latency).CombinedOutput()
+				if err != nil {
+					return errors.Errorf("failed to add latency with tc: %s", string(output))
+				}
+				return nil
+			}, func() error {
+				output, err := exec.Command("tc", "qdisc", "del", "dev", device, "root", "netem").CombinedOutput()
+				if err != nil {
+					return errors.Errorf("failed to remove the latency qdisc, remove it manually with 'tc qdisc del dev %s root netem': %s", device, string(output))
+				}
+				return nil
+			})
+		},
+	}
+	addChaosFlags(cmd, opts)
+	cmd.Flags().StringVar(&device, "interface", "eth0",
+		"Network interface the latency is added on")
+	cmd.Flags().StringVar(&latency, "latency", "200ms",
+		"Added delay, e.g. 200ms")
+	return cmd
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	coordinationv1 "k8s.io/api/coordination/v1"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var checkHeartbeatLongDescription = `
+    Compare the renewTime of the node Lease cached in the edge database
+    against the current time. A node that reports Ready locally but shows
+    NotReady in the cloud usually stopped renewing its lease; this tells
+    how far behind the heartbeat is without access to the cloud apiserver.
+`
+
+// checkHeartbeatGrace is how many lease durations may pass since the last
+// renewal before the heartbeat counts as stale, matching the apiserver's
+// node lease grace period
+const checkHeartbeatGrace = 2
+
+// CheckOptions has the check subcommand information filled by CLI
+type CheckOptions struct {
+	EdgecoreConfig   string
+	Input            string
+	CheckPermissions bool
+}
+
+// NewCheckOptions returns check options with defaults
+func NewCheckOptions() *CheckOptions {
+	return &CheckOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+}
+
+// addCheckFlags registers the flags shared by the check subcommands
+func addCheckFlags(cmd *cobra.Command, opts *CheckOptions) {
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	cmd.Flags().BoolVar(&opts.CheckPermissions, "check-permissions", opts.CheckPermissions,
+		"Only verify access to the paths the command needs and report the results")
+}
+
+// NewCheck returns the cobra command grouping the edge health checks
+func NewCheck(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Health checks for the edge node",
+	}
+	cmd.AddCommand(NewCheckHeartbeat(out))
+	cmd.AddCommand(NewCheckVolumes(out))
+	cmd.AddCommand(NewCheckTime(out))
+	cmd.AddCommand(NewCheckTLS(out))
+	cmd.AddCommand(NewCheckMappers(out))
+	return cmd
+}
+
+// NewCheckHeartbeat returns the cobra command checking the node lease heartbeat
+func NewCheckHeartbeat(out io.Writer) *cobra.Command {
+	opts := NewCheckOptions()
+	cmd := &cobra.Command{
+		Use:   "heartbeat",
+		Short: "Check how far behind the node lease heartbeat is",
+		Long:  checkHeartbeatLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCheckHeartbeat(out, opts)
+		},
+	}
+	addCheckFlags(cmd, opts)
+	return cmd
+}
+
+// RunCheckHeartbeat compares the renewTime of the cached node leases
+// against now and reports which of them have gone stale
+func RunCheckHeartbeat(out io.Writer, opts *CheckOptions) error {
+	if opts.CheckPermissions {
+		return RunCheckPermissions(out, opts.EdgecoreConfig, opts.Input)
+	}
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	metas, err := dao.QueryMeta("type", "lease")
+	if err != nil {
+		return errors.Wrap(err, "failed to query the edge database")
+	}
+	if len(*metas) == 0 {
+		return errors.New("no lease is cached in the edge database, the node may never have registered or leases are not synced")
+	}
+
+	stale := 0
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("NAME\tRENEWED\tDURATION\tRESULT"))
+	for _, value := range *metas {
+		lease := coordinationv1.Lease{}
+		if err := json.Unmarshal([]byte(value), &lease); err != nil {
+			return errors.Wrap(err, "failed to unmarshal a cached lease")
+		}
+		duration := 40 * time.Second
+		if lease.Spec.LeaseDurationSeconds != nil {
+			duration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+		}
+		renewed := "<never>"
+		behind := time.Duration(0)
+		ok := false
+		if lease.Spec.RenewTime != nil {
+			behind = time.Since(lease.Spec.RenewTime.Time)
+			renewed = fmt.Sprintf("%s ago", humanDuration(behind))
+			ok = behind <= checkHeartbeatGrace*duration
+		}
+		if !ok {
+			stale++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%v\t%s\n", lease.Name, renewed, duration, checkVerdict(ok))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if stale > 0 {
+		return errors.Errorf("%d of %d leases are stale; the cloud will mark the node NotReady while edgehub cannot deliver heartbeats, check the websocket connection to the cloud", stale, len(*metas))
+	}
+	return nil
+}
+
+// checkVerdict renders a health check result
+func checkVerdict(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "STALE"
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	ps "github.com/mitchellh/go-ps"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtclient"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1"
+)
+
+var checkMappersLongDescription = `
+    Discover the device mappers running on this node, verify the MQTT
+    broker they publish through is reachable, and compare the last
+    telemetry timestamp of every device in the twin database against
+    --max-silence. A mapper that is up while its devices stop reporting
+    fails silently; this makes the silence visible.
+`
+
+// mapperKinds are the mappers this check knows how to discover, by the
+// substring their container or process name carries
+var mapperKinds = []string{"modbus", "bluetooth", "opcua"}
+
+// CheckMappersOptions has the check mappers subcommand information filled by CLI
+type CheckMappersOptions struct {
+	EdgecoreConfig string
+	Input          string
+	MqttServer     string
+	MaxSilence     time.Duration
+}
+
+// NewCheckMappers returns the cobra command verifying mapper health
+func NewCheckMappers(out io.Writer) *cobra.Command {
+	opts := &CheckMappersOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+		MaxSilence:     5 * time.Minute,
+	}
+	cmd := &cobra.Command{
+		Use:   "mappers",
+		Short: "Verify device mappers are running and their devices report",
+		Long:  checkMappersLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCheckMappers(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database and the broker")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	cmd.Flags().StringVar(&opts.MqttServer, "mqtt-server", opts.MqttServer,
+		"MQTT broker url to probe, overriding the configured one")
+	cmd.Flags().DurationVar(&opts.MaxSilence, "max-silence", opts.MaxSilence,
+		"How long a device may go without telemetry before it counts as silent")
+	return cmd
+}
+
+// RunCheckMappers runs the discovery, the broker probe and the silence check
+func RunCheckMappers(out io.Writer, opts *CheckMappersOptions) error {
+	registerDeviceModels()
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+
+	failed := 0
+
+	mappers := discoverMappers()
+	if len(mappers) == 0 {
+		fmt.Fprintf(out, T("Mappers:     none discovered (looked for %s)\n"), strings.Join(mapperKinds, ", "))
+	} else {
+		fmt.Fprintf(out, T("Mappers:     %s\n"), strings.Join(mappers, ", "))
+	}
+
+	broker := opts.MqttServer
+	if broker == "" {
+		if config, err := LoadEdgecoreConfig(opts.EdgecoreConfig); err == nil {
+			broker = mapperBroker(config.Modules.EventBus)
+		}
+	}
+	if broker == "" {
+		fmt.Fprintf(out, T("MQTT broker: none configured\n"))
+	} else if latency, err := probeMqttBroker(broker); err != nil {
+		fmt.Fprintf(out, T("MQTT broker: %s unreachable: %v\n"), broker, err)
+		failed++
+	} else {
+		fmt.Fprintf(out, T("MQTT broker: %s reachable in %s\n"), broker, latency.Round(time.Millisecond))
+	}
+
+	var devices []dtclient.Device
+	if _, err := dbm.DBAccess.QueryTable(dtclient.DeviceTableName).All(&devices); err != nil {
+		return errors.Wrap(err, "failed to query the device table")
+	}
+	if len(devices) == 0 {
+		fmt.Fprintf(out, T("Devices:     none in the twin database\n"))
+		if failed > 0 {
+			return errors.Errorf("%d mapper problems found", failed)
+		}
+		return nil
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("DEVICE\tSTATE\tLAST TELEMETRY\tRESULT"))
+	for i := range devices {
+		device := &devices[i]
+		last, verdict := deviceTelemetryVerdict(device.ID, opts.MaxSilence)
+		if verdict != "ok" {
+			failed++
+		}
+		name := device.Name
+		if name == "" {
+			name = device.ID
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, device.State, last, verdict)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("%d mapper problems found", failed)
+	}
+	return nil
+}
+
+// discoverMappers finds mapper containers and processes by name
+func discoverMappers() []string {
+	found := map[string]bool{}
+	if output, err := exec.Command("docker", "ps", "--format", "{{.Names}}\t{{.Image}}").Output(); err == nil {
+		for _, line := range strings.Split(strings.ToLower(string(output)), "\n") {
+			for _, kind := range mapperKinds {
+				if strings.Contains(line, kind) {
+					found[kind] = true
+				}
+			}
+		}
+	}
+	if processes, err := ps.Processes(); err == nil {
+		for _, process := range processes {
+			name := strings.ToLower(process.Executable())
+			for _, kind := range mapperKinds {
+				if strings.Contains(name, kind) {
+					found[kind] = true
+				}
+			}
+		}
+	}
+	var mappers []string
+	for _, kind := range mapperKinds {
+		if found[kind] {
+			mappers = append(mappers, kind)
+		}
+	}
+	return mappers
+}
+
+// mapperBroker is the broker url mappers publish through: the external
+// broker unless eventbus runs the internal one exclusively
+func mapperBroker(eventBus *v1alpha1.EventBus) string {
+	if eventBus == nil {
+		return ""
+	}
+	if eventBus.MqttMode == v1alpha1.MqttModeInternal {
+		return eventBus.MqttServerInternal
+	}
+	return eventBus.MqttServerExternal
+}
+
+// probeMqttBroker verifies the broker port accepts connections
+func probeMqttBroker(broker string) (time.Duration, error) {
+	address := broker
+	if parsed, err := url.Parse(broker); err == nil && parsed.Host != "" {
+		address = parsed.Host
+	}
+	before := time.Now()
+	conn, err := net.DialTimeout("tcp", address, 5*time.Second)
+	if err != nil {
+		return 0, err
+	}
+	conn.Close()
+	return time.Since(before), nil
+}
+
+// deviceTelemetryVerdict finds the newest actual twin timestamp of the
+// device and judges it against the silence limit
+func deviceTelemetryVerdict(deviceID string, maxSilence time.Duration) (string, string) {
+	var twins []dtclient.DeviceTwin
+	if _, err := dbm.DBAccess.QueryTable(dtclient.DeviceTwinTableName).Filter("deviceid", deviceID).All(&twins); err != nil {
+		return "-", fmt.Sprintf("FAIL: %v", err)
+	}
+	var newest time.Time
+	for i := range twins {
+		if t, ok := twinTimestamp(twins[i].ActualMeta); ok && t.After(newest) {
+			newest = t
+		}
+	}
+	switch {
+	case newest.IsZero():
+		return "never", "SILENT: no actual value ever reported"
+	case time.Since(newest) > maxSilence:
+		return newest.Format(time.RFC3339), fmt.Sprintf("SILENT: last telemetry %s ago", time.Since(newest).Round(time.Second))
+	default:
+		return newest.Format(time.RFC3339), "ok"
+	}
+}
+
+// twinTimestamp parses the timestamp out of a twin metadata document,
+// which carries milliseconds since the epoch
+func twinTimestamp(metadata string) (time.Time, bool) {
+	if metadata == "" {
+		return time.Time{}, false
+	}
+	var meta struct {
+		Timestamp int64 `json:"timestamp"`
+	}
+	if err := json.Unmarshal([]byte(metadata), &meta); err != nil || meta.Timestamp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, meta.Timestamp*int64(time.Millisecond)), true
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var checkTimeLongDescription = `
+    Measure the offset between this node's clock and cloudcore using the
+    Date header of the certificate HTTP endpoint. Skew beyond a minute or
+    two breaks certificate validation and token auth, and boards without
+    an RTC drift there easily after a power cut.
+`
+
+// checkTimeSkewLimit is the offset beyond which the check fails; TLS
+// certificate notBefore/notAfter checks and JWT validation tolerate
+// roughly this much
+const checkTimeSkewLimit = 2 * time.Minute
+
+// CheckTimeOptions has the check time subcommand information filled by CLI
+type CheckTimeOptions struct {
+	EdgecoreConfig string
+	Server         string
+	Timeout        time.Duration
+}
+
+// NewCheckTime returns the cobra command measuring clock skew
+func NewCheckTime(out io.Writer) *cobra.Command {
+	opts := &CheckTimeOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+		Timeout:        10 * time.Second,
+	}
+	cmd := &cobra.Command{
+		Use:   "time",
+		Short: "Measure the clock offset between this node and cloudcore",
+		Long:  checkTimeLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCheckTime(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration holding the cloudcore endpoint")
+	cmd.Flags().StringVar(&opts.Server, "server", opts.Server,
+		"Cloudcore HTTP endpoint to measure against, overriding the configured one")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout,
+		"How long to wait for the cloudcore response")
+	return cmd
+}
+
+// RunCheckTime measures the offset and fails on skew that breaks auth
+func RunCheckTime(out io.Writer, opts *CheckTimeOptions) error {
+	server := opts.Server
+	if server == "" {
+		config, err := LoadEdgecoreConfig(opts.EdgecoreConfig)
+		if err != nil {
+			return err
+		}
+		server = config.Modules.EdgeHub.HTTPServer
+	}
+	if server == "" {
+		return errors.New("no cloudcore endpoint configured, give one with --server")
+	}
+
+	offset, roundtrip, err := measureClockOffset(server, opts.Timeout)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, T("Cloudcore:  %s\n"), server)
+	fmt.Fprintf(out, T("Roundtrip:  %v\n"), roundtrip)
+	fmt.Fprintf(out, T("Offset:     %v (positive means this node is ahead)\n"), offset)
+
+	skew := offset
+	if skew < 0 {
+		skew = -skew
+	}
+	// the Date header has second granularity, the roundtrip bounds how
+	// much of the offset the measurement itself may account for
+	if skew > checkTimeSkewLimit+roundtrip {
+		return errors.Errorf("the clock is off by %v, certificate validation and token auth will fail; sync the clock, e.g. with ntpdate or chrony", offset)
+	}
+	fmt.Fprintf(out, T("Result:     ok, within the %v tolerance\n"), checkTimeSkewLimit)
+	return nil
+}
+
+// measureClockOffset compares the local clock with the Date header of
+// the cloudcore response
+func measureClockOffset(server string, timeout time.Duration) (offset, roundtrip time.Duration, err error) {
+	client := &http.Client{
+		Timeout: timeout,
+		// the point is reading the Date header, a certificate this node
+		// cannot validate yet, e.g. because of the very skew measured
+		// here, must not stop the measurement
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	before := time.Now()
+	response, err := client.Head(server)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "failed to reach cloudcore at %s", server)
+	}
+	defer response.Body.Close()
+	after := time.Now()
+
+	date := response.Header.Get("Date")
+	if date == "" {
+		return 0, 0, errors.Errorf("cloudcore at %s sent no Date header", server)
+	}
+	remote, err := http.ParseTime(date)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "cloudcore sent an unparsable Date header %q", date)
+	}
+	roundtrip = after.Sub(before)
+	// assume the server stamped the response mid-roundtrip
+	local := before.Add(roundtrip / 2)
+	return local.Sub(remote).Round(time.Millisecond), roundtrip.Round(time.Millisecond), nil
+}
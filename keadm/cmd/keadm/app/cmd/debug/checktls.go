@@ -0,0 +1,169 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var checkTLSLongDescription = `
+    Perform the same mutual TLS handshake edgehub performs against the
+    cloudcore websocket port, with the node's client certificate, and
+    report SNI, the negotiated version and cipher, the certificate chain
+    the server presented and the exact verification failure: expired,
+    signed by the wrong CA or a hostname mismatch. The edgehub logs only
+    show generic reconnect loops for all of these.
+`
+
+// CheckTLSOptions has the check tls subcommand information filled by CLI
+type CheckTLSOptions struct {
+	EdgecoreConfig string
+	Server         string
+	Timeout        time.Duration
+}
+
+// NewCheckTLS returns the cobra command diagnosing the TLS handshake
+func NewCheckTLS(out io.Writer) *cobra.Command {
+	opts := &CheckTLSOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+		Timeout:        10 * time.Second,
+	}
+	cmd := &cobra.Command{
+		Use:   "tls",
+		Short: "Diagnose the mutual TLS handshake with cloudcore",
+		Long:  checkTLSLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCheckTLS(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration holding the endpoint and certificate paths")
+	cmd.Flags().StringVar(&opts.Server, "server", opts.Server,
+		"host:port to handshake with, overriding the configured websocket server")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout,
+		"How long to wait for the handshake")
+	return cmd
+}
+
+// RunCheckTLS performs the handshake and explains what it negotiated
+func RunCheckTLS(out io.Writer, opts *CheckTLSOptions) error {
+	config, err := LoadEdgecoreConfig(opts.EdgecoreConfig)
+	if err != nil {
+		return err
+	}
+	server := opts.Server
+	if server == "" {
+		server = config.Modules.EdgeHub.WebSocket.Server
+	}
+	if server == "" {
+		return errors.New("no websocket server configured, give one with --server")
+	}
+	host, _, err := net.SplitHostPort(server)
+	if err != nil {
+		return errors.Errorf("%s is not a host:port", server)
+	}
+
+	tlsConfig := &tls.Config{ServerName: host}
+	certDetail := "none loaded"
+	if certificate, err := tls.LoadX509KeyPair(config.Modules.EdgeHub.TLSCertFile, config.Modules.EdgeHub.TLSPrivateKeyFile); err == nil {
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+		certDetail = config.Modules.EdgeHub.TLSCertFile
+	} else {
+		fmt.Fprintf(out, T("warning: no client certificate (%v), the server may reject the handshake\n"), err)
+	}
+	roots, rootsDetail := loadCheckTLSRoots(config.Modules.EdgeHub.TLSCAFile)
+	tlsConfig.RootCAs = roots
+
+	fmt.Fprintf(out, T("Server:       %s (SNI %s)\n"), server, host)
+	fmt.Fprintf(out, T("Client cert:  %s\n"), certDetail)
+	fmt.Fprintf(out, T("CA bundle:    %s\n"), rootsDetail)
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", server, tlsConfig)
+	if err != nil {
+		return explainHandshakeError(err, host)
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	fmt.Fprintf(out, T("Negotiated:   %s, %s\n"), tlsVersionName(state.Version), tls.CipherSuiteName(state.CipherSuite))
+	for i, certificate := range state.PeerCertificates {
+		fmt.Fprintf(out, T("Peer cert %d:  %s, issued by %s, valid %s to %s\n"), i,
+			certificate.Subject.CommonName, certificate.Issuer.CommonName,
+			certificate.NotBefore.Format("2006-01-02"), certificate.NotAfter.Format("2006-01-02"))
+	}
+	fmt.Fprintf(out, T("Result:       handshake succeeded\n"))
+	return nil
+}
+
+// loadCheckTLSRoots loads the configured CA bundle, falling back to the
+// system roots with a note
+func loadCheckTLSRoots(path string) (*x509.CertPool, string) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Sprintf("%s not readable, using system roots", path)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Sprintf("%s holds no usable certificates, using system roots", path)
+	}
+	return pool, path
+}
+
+// explainHandshakeError turns the crypto/tls errors into the three
+// failures field staff actually hit
+func explainHandshakeError(err error, host string) error {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "certificate has expired"):
+		return errors.Errorf("the server certificate is expired or not yet valid (%v); check the cloudcore certificate and this node's clock with keadm debug check time", err)
+	case strings.Contains(message, "signed by unknown authority"):
+		return errors.Errorf("the server certificate is signed by a different CA than the configured bundle (%v); the node probably joined a different cloudcore than it now dials", err)
+	case strings.Contains(message, "not valid for"), strings.Contains(message, "doesn't contain any IP SANs"):
+		return errors.Errorf("the server certificate does not cover %s (%v); cloudcore was started with other advertise addresses", host, err)
+	case strings.Contains(message, "bad certificate"), strings.Contains(message, "unknown ca"):
+		return errors.Errorf("the server rejected this node's client certificate (%v); re-join the node to get a fresh one", err)
+	default:
+		return errors.Wrap(err, "handshake failed")
+	}
+}
+
+// tlsVersionName names the negotiated protocol version
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("unknown (0x%04x)", version)
+	}
+}
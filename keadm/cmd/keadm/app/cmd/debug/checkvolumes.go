@@ -0,0 +1,196 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var checkVolumesLongDescription = `
+    Enumerate the volumes of the cached pods and verify what they mount:
+    hostPath sources must exist with the declared type and be accessible,
+    configMap and secret sources must be cached in the edge database, and
+    containers stuck on mount problems are reported with the runtime's
+    waiting reason.
+`
+
+// NewCheckVolumes returns the cobra command verifying pod volumes
+func NewCheckVolumes(out io.Writer) *cobra.Command {
+	opts := NewCheckOptions()
+	namespace := "default"
+	cmd := &cobra.Command{
+		Use:   "volumes [pod]",
+		Short: "Verify the volume sources of cached pods",
+		Long:  checkVolumesLongDescription,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			return RunCheckVolumes(out, opts, namespace, name)
+		},
+	}
+	addCheckFlags(cmd, opts)
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", namespace,
+		"Namespace of the pods")
+	return cmd
+}
+
+// RunCheckVolumes verifies the volume sources of the selected pods
+func RunCheckVolumes(out io.Writer, opts *CheckOptions, namespace, name string) error {
+	if opts.CheckPermissions {
+		return RunCheckPermissions(out, opts.EdgecoreConfig, opts.Input)
+	}
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	metas, err := dao.QueryAllMeta("type", "pod")
+	if err != nil {
+		return errors.Wrap(err, "failed to query the edge database")
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("POD\tVOLUME\tTYPE\tRESULT\tDETAIL"))
+	failed, checked := 0, 0
+	for i := range *metas {
+		meta := &(*metas)[i]
+		if keyNamespace(meta.Key) != namespace {
+			continue
+		}
+		if name != "" && keyName(meta.Key) != name {
+			continue
+		}
+		pod, err := MetaToPod(meta)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t\t\tFAIL\tundecodable: %v\n", keyName(meta.Key), err)
+			failed++
+			checked++
+			continue
+		}
+		checked++
+		for _, volume := range pod.Spec.Volumes {
+			ok, detail := checkVolumeSource(&volume, namespace)
+			if !ok {
+				failed++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				pod.Name, volume.Name, volumeTypeName(&volume), volumeVerdict(ok), detail)
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting == nil {
+				continue
+			}
+			// the runtime parks containers with mount problems in reasons
+			// like CreateContainerConfigError, surface them alongside
+			failed++
+			fmt.Fprintf(w, "%s\t<container %s>\t\tFAIL\t%s: %s\n",
+				pod.Name, status.Name, status.State.Waiting.Reason, status.State.Waiting.Message)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if checked == 0 {
+		if name != "" {
+			return errors.Errorf("pod %s is not cached in namespace %s", name, namespace)
+		}
+		fmt.Fprintf(out, T("No resources found\n"))
+		return nil
+	}
+	if failed > 0 {
+		return errors.Errorf("%d volume problems found", failed)
+	}
+	return nil
+}
+
+// checkVolumeSource verifies one volume source on this node
+func checkVolumeSource(volume *v1.Volume, namespace string) (bool, string) {
+	switch {
+	case volume.HostPath != nil:
+		return checkHostPathSource(volume.HostPath)
+	case volume.ConfigMap != nil:
+		return checkDBSource(namespace, "configmap", volume.ConfigMap.Name, volume.ConfigMap.Optional)
+	case volume.Secret != nil:
+		return checkDBSource(namespace, "secret", volume.Secret.SecretName, volume.Secret.Optional)
+	default:
+		return true, "nothing to verify on this node"
+	}
+}
+
+// checkHostPathSource verifies existence, declared type and access
+func checkHostPathSource(source *v1.HostPathVolumeSource) (bool, string) {
+	info, err := os.Stat(source.Path)
+	if err != nil {
+		if source.Type != nil && (*source.Type == v1.HostPathDirectoryOrCreate || *source.Type == v1.HostPathFileOrCreate) {
+			return true, fmt.Sprintf("%s does not exist yet, the kubelet will create it", source.Path)
+		}
+		return false, fmt.Sprintf("%s does not exist on this node", source.Path)
+	}
+	if source.Type != nil {
+		switch *source.Type {
+		case v1.HostPathDirectory, v1.HostPathDirectoryOrCreate:
+			if !info.IsDir() {
+				return false, fmt.Sprintf("%s is not a directory", source.Path)
+			}
+		case v1.HostPathFile, v1.HostPathFileOrCreate:
+			if info.IsDir() {
+				return false, fmt.Sprintf("%s is a directory, not a file", source.Path)
+			}
+		}
+	}
+	// opening is the only reliable access check, permission bits lie
+	// once ACLs or ownership enter the picture
+	handle, err := os.Open(source.Path)
+	if err != nil {
+		return false, fmt.Sprintf("%s exists but is not accessible: %v", source.Path, err)
+	}
+	handle.Close()
+	return true, fmt.Sprintf("%s, mode %s", source.Path, info.Mode())
+}
+
+// checkDBSource verifies a configmap or secret is cached in the database
+func checkDBSource(namespace, resType, name string, optional *bool) (bool, string) {
+	metas, err := dao.QueryAllMeta("key", namespace+"/"+resType+"/"+name)
+	if err != nil {
+		return false, err.Error()
+	}
+	if len(*metas) > 0 {
+		return true, "cached in the edge database"
+	}
+	if optional != nil && *optional {
+		return true, "not cached, but the volume is optional"
+	}
+	return false, fmt.Sprintf("%s %s/%s is not cached, the mount will fail", resType, namespace, name)
+}
+
+// volumeVerdict renders a volume check result
+func volumeVerdict(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "FAIL"
+}
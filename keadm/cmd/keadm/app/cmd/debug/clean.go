@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+)
+
+// cleanMetadataFields are the server-populated metadata fields stripped
+// by the clean-yaml output, they only describe the object's life on the
+// apiserver it came from and get in the way of reviewing or re-applying
+// the manifest
+var cleanMetadataFields = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"selfLink",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"managedFields",
+	"finalizers",
+}
+
+// cleanAnnotations are the bookkeeping annotations stripped by the
+// clean-yaml output
+var cleanAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+}
+
+// cleanValue strips the server-populated noise from a stored value so
+// the remaining manifest reads like what the author applied: the status,
+// the metadata bookkeeping fields and the annotations the control plane
+// maintains. Values that do not decode pass through untouched
+func cleanValue(value string) string {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &obj); err != nil {
+		return value
+	}
+	delete(obj, "status")
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		for _, field := range cleanMetadataFields {
+			delete(metadata, field)
+		}
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			for _, annotation := range cleanAnnotations {
+				delete(annotations, annotation)
+			}
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return value
+	}
+	return string(data)
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub"
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/common"
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/util"
+)
+
+var collectLongDescription = `
+    Collect diagnostic bundles from many connected edge nodes at once,
+    from the cloud side. The requests fan out in parallel through the
+    cloudhub connection of each node, the bundles stream back the same
+    way, and one archive per node lands in --output-dir with a summary of
+    the nodes that failed. Run this next to cloudcore, it talks through
+    the cloudhub unix socket.
+`
+
+var collectExample = `
+    # Collect from two nodes by name
+    keadm debug collect --nodes edge-node1,edge-node2
+
+    # Collect from every node carrying a label
+    keadm debug collect -l node-role.kubernetes.io/edge=
+`
+
+// collectSocketDefault is the cloudhub unix socket the requests go
+// through, the unixsocket address of the cloudcore configuration
+const collectSocketDefault = "/var/lib/kubeedge/kubeedge.sock"
+
+// CollectOptions has the collect subcommand information filled by CLI
+type CollectOptions struct {
+	Nodes      string
+	Selector   string
+	KubeConfig string
+	Socket     string
+	OutputDir  string
+	Timeout    time.Duration
+	Logs       bool
+	Since      time.Duration
+	LogLimit   int64
+}
+
+// collectResult is the outcome of one node
+type collectResult struct {
+	node string
+	file string
+	size int
+	err  error
+}
+
+// NewCollect returns the cobra command fanning collection out to nodes
+func NewCollect(out io.Writer) *cobra.Command {
+	opts := &CollectOptions{
+		KubeConfig: common.DefaultKubeConfig,
+		Socket:     collectSocketDefault,
+		OutputDir:  ".",
+		Timeout:    90 * time.Second,
+		Since:      time.Hour,
+		LogLimit:   128 * 1024,
+	}
+	cmd := &cobra.Command{
+		Use:     "collect",
+		Short:   "Collect diagnostic bundles from many edge nodes through cloudhub",
+		Long:    collectLongDescription,
+		Example: collectExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCollect(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Nodes, "nodes", opts.Nodes,
+		"Comma separated node names to collect from")
+	cmd.Flags().StringVarP(&opts.Selector, "selector", "l", opts.Selector,
+		"Label selector choosing the nodes, resolved through the API server")
+	cmd.Flags().StringVar(&opts.KubeConfig, "kube-config", opts.KubeConfig,
+		"Path of the kubeconfig used to resolve --selector")
+	cmd.Flags().StringVar(&opts.Socket, "socket", opts.Socket,
+		"Path of the cloudhub unix socket")
+	cmd.Flags().StringVar(&opts.OutputDir, "output-dir", opts.OutputDir,
+		"Directory the per node archives are written into")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout,
+		"How long to wait for each node")
+	cmd.Flags().BoolVar(&opts.Logs, "logs", opts.Logs,
+		"Also harvest edgecore and container runtime logs on each node")
+	cmd.Flags().DurationVar(&opts.Since, "since", opts.Since,
+		"How far back the harvested logs reach")
+	cmd.Flags().Int64Var(&opts.LogLimit, "log-limit", opts.LogLimit,
+		"Byte cap per harvested log, the newest end is kept")
+	return cmd
+}
+
+// RunCollect resolves the nodes, fans the requests out and summarizes
+func RunCollect(out io.Writer, opts *CollectOptions) error {
+	nodes, err := resolveCollectNodes(opts)
+	if err != nil {
+		return err
+	}
+
+	// one in flight request per node; cloudhub serializes per node anyway
+	results := make([]collectResult, len(nodes))
+	forEachParallel(len(nodes), func(index int) {
+		results[index] = collectFromNode(opts, nodes[index])
+	})
+
+	failed := 0
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("NODE\tRESULT\tBUNDLE"))
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			fmt.Fprintf(w, "%s\tFAIL: %v\t-\n", result.node, result.err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\tok (%s)\t%s\n", result.node, humanBytes(int64(result.size)), result.file)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d nodes failed to deliver a bundle", failed, len(nodes))
+	}
+	return nil
+}
+
+// resolveCollectNodes turns --nodes or --selector into node names
+func resolveCollectNodes(opts *CollectOptions) ([]string, error) {
+	if opts.Nodes != "" && opts.Selector != "" {
+		return nil, errors.New("give either --nodes or --selector, not both")
+	}
+	if opts.Nodes != "" {
+		var nodes []string
+		for _, node := range strings.Split(opts.Nodes, ",") {
+			if node = strings.TrimSpace(node); node != "" {
+				nodes = append(nodes, node)
+			}
+		}
+		if len(nodes) > 0 {
+			return nodes, nil
+		}
+	}
+	if opts.Selector == "" {
+		return nil, errors.New("one of --nodes and --selector is required")
+	}
+	client, err := util.KubeClient(opts.KubeConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build a client for the cloud API server, is it reachable from here?")
+	}
+	list, err := client.CoreV1().Nodes().List(metav1.ListOptions{LabelSelector: opts.Selector})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the nodes matching %s", opts.Selector)
+	}
+	if len(list.Items) == 0 {
+		return nil, errors.Errorf("no node matches %s", opts.Selector)
+	}
+	nodes := make([]string, 0, len(list.Items))
+	for i := range list.Items {
+		nodes = append(nodes, list.Items[i].Name)
+	}
+	return nodes, nil
+}
+
+// collectFromNode sends one collect request through cloudhub and writes
+// the returned bundle
+func collectFromNode(opts *CollectOptions, node string) collectResult {
+	result := collectResult{node: node}
+	// the resource carries the node for the cloudhub dispatch, the debug
+	// segment routes the response back to the socket waiter
+	message := model.NewMessage("").
+		BuildRouter("keadm", "resource", "node/"+node+"/default/debug/collect", edgehub.OperationCollect)
+	// timeoutSeconds tells cloudhub how long to hold the exchange open,
+	// without it the socket waiter falls back to its one minute default
+	request, err := json.Marshal(map[string]interface{}{
+		"logs":           opts.Logs,
+		"since":          opts.Since.String(),
+		"logLimit":       opts.LogLimit,
+		"timeoutSeconds": int64(opts.Timeout / time.Second),
+	})
+	if err != nil {
+		result.err = err
+		return result
+	}
+	message.FillBody(string(request))
+	response, err := sendCloudHubRequest(opts.Socket, opts.Timeout, message)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	content := fmt.Sprintf("%v", response.GetContent())
+	var bundle struct {
+		Filename string `json:"filename"`
+		Data     string `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(content), &bundle); err != nil || bundle.Data == "" {
+		// the edge answers errors as plain text
+		result.err = errors.Errorf("%s", content)
+		return result
+	}
+	data, err := base64.StdEncoding.DecodeString(bundle.Data)
+	if err != nil {
+		result.err = errors.Wrap(err, "malformed bundle")
+		return result
+	}
+	result.file = filepath.Join(opts.OutputDir, node+"-"+bundle.Filename)
+	result.size = len(data)
+	if err := ioutil.WriteFile(result.file, data, 0600); err != nil {
+		result.err = err
+		result.file = ""
+	}
+	return result
+}
+
+// sendCloudHubRequest performs one exchange over the cloudhub unix
+// socket: a marshaled message in, the marshaled response out
+func sendCloudHubRequest(socket string, timeout time.Duration, message *model.Message) (*model.Message, error) {
+	request, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialTimeout("unix", strings.TrimPrefix(socket, "unix://"), 10*time.Second)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reach the cloudhub socket, is cloudcore running here?")
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(conn)
+	if err != nil && len(data) == 0 {
+		return nil, errors.Wrap(err, "no response from cloudhub")
+	}
+	response := &model.Message{}
+	if err := json.Unmarshal(data, response); err != nil {
+		return nil, errors.Wrap(err, "unreadable response from cloudhub")
+	}
+	return response, nil
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// keadmConfigEnv points --context resolution at an alternative keadm
+// config, e.g. one kept next to a pile of site snapshots
+const keadmConfigEnv = "KEADM_CONFIG"
+
+// keadmConfigSystemPath is the keadm config read on a node, the per user
+// one under the home directory wins when it exists
+const keadmConfigSystemPath = "/etc/kubeedge/keadm.yaml"
+
+// KeadmConfig is the keadm configuration holding the named contexts
+type KeadmConfig struct {
+	// CurrentContext is applied when --context is not given
+	CurrentContext string `json:"current-context,omitempty"`
+	Contexts       map[string]*DebugContext `json:"contexts,omitempty"`
+}
+
+// DebugContext is one named set of paths the debug subcommands read,
+// typically one snapshot directory per site
+type DebugContext struct {
+	// EdgecoreConfig replaces --edgecore-config
+	EdgecoreConfig string `json:"edgecore-config,omitempty"`
+	// Database replaces --input, pointing at a database snapshot
+	Database string `json:"database,omitempty"`
+	// DeviceDatabase points the twin reading commands at a separately
+	// copied device database; unset they share Database, which matches
+	// edgecore keeping both table families in one file
+	DeviceDatabase string `json:"device-database,omitempty"`
+	// CRIEndpoint replaces --cri-endpoint of the runtime diagnostics
+	CRIEndpoint string `json:"cri-endpoint,omitempty"`
+}
+
+// keadmConfigPath is where the keadm config is looked for, in order: the
+// environment override, the per user file, the system file
+func keadmConfigPath() string {
+	if path := os.Getenv(keadmConfigEnv); path != "" {
+		return path
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		personal := filepath.Join(home, ".kubeedge", "keadm.yaml")
+		if _, err := os.Stat(personal); err == nil {
+			return personal
+		}
+	}
+	return keadmConfigSystemPath
+}
+
+// loadKeadmConfig reads the keadm config, a missing file is an empty
+// config so nodes without one keep working
+func loadKeadmConfig() (*KeadmConfig, string, error) {
+	path := keadmConfigPath()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &KeadmConfig{}, path, nil
+		}
+		return nil, path, errors.Wrapf(err, "failed to read keadm config %s", path)
+	}
+	config := &KeadmConfig{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, path, errors.Wrapf(err, "failed to parse keadm config %s", path)
+	}
+	return config, path, nil
+}
+
+// applyContext resolves the selected context and writes its values into
+// the unset flags of the invoked subcommand, so explicit flags always win
+func applyContext(invoked *cobra.Command, name string) error {
+	config, path, err := loadKeadmConfig()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = config.CurrentContext
+	}
+	if name == "" {
+		return nil
+	}
+	context, ok := config.Contexts[name]
+	if !ok {
+		if len(config.Contexts) == 0 {
+			return errors.Errorf("context %s selected but %s defines no contexts", name, path)
+		}
+		return errors.Errorf("context %s is not in %s; defined contexts: %s",
+			name, path, joinSorted(contextNames(config)))
+	}
+	overrideFlag(invoked, "edgecore-config", context.EdgecoreConfig)
+	overrideFlag(invoked, "input", contextDatabase(invoked, context))
+	overrideFlag(invoked, "cri-endpoint", context.CRIEndpoint)
+	return nil
+}
+
+// contextDatabase picks the database of the context for the invoked
+// command: the twin reading commands prefer the device database
+func contextDatabase(invoked *cobra.Command, context *DebugContext) string {
+	if context.DeviceDatabase != "" && readsTwinDatabase(invoked) {
+		return context.DeviceDatabase
+	}
+	return context.Database
+}
+
+// readsTwinDatabase tells whether the command works against the twin
+// tables rather than the meta table
+func readsTwinDatabase(invoked *cobra.Command) bool {
+	for cmd := invoked; cmd != nil; cmd = cmd.Parent() {
+		switch cmd.Name() {
+		case "twin", "telemetry", "mappers":
+			return true
+		}
+	}
+	return false
+}
+
+// overrideFlag sets the named flag of the invoked command when the
+// context provides a value and the caller did not give the flag
+func overrideFlag(invoked *cobra.Command, name, value string) {
+	if value == "" {
+		return
+	}
+	flag := invoked.Flags().Lookup(name)
+	if flag == nil || flag.Changed {
+		return
+	}
+	flag.Value.Set(value)
+}
+
+// contextNames lists the context names of the config
+func contextNames(config *KeadmConfig) []string {
+	names := make([]string, 0, len(config.Contexts))
+	for name := range config.Contexts {
+		names = append(names, name)
+	}
+	return names
+}
+
+// joinSorted joins names sorted, for stable messages and listings
+func joinSorted(names []string) string {
+	sort.Strings(names)
+	joined := ""
+	for i, name := range names {
+		if i > 0 {
+			joined += ", "
+		}
+		joined += name
+	}
+	return joined
+}
+
+// NewContext returns the cobra command grouping the context tools
+func NewContext(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Inspect the named contexts of the keadm config",
+	}
+	cmd.AddCommand(NewContextList(out))
+	return cmd
+}
+
+// NewContextList returns the cobra command listing the contexts
+func NewContextList(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the contexts of the keadm config",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunContextList(out)
+		},
+	}
+	return cmd
+}
+
+// RunContextList prints the contexts, the current one marked
+func RunContextList(out io.Writer) error {
+	config, path, err := loadKeadmConfig()
+	if err != nil {
+		return err
+	}
+	if len(config.Contexts) == 0 {
+		fmt.Fprintf(out, T("No contexts in %s\n"), path)
+		return nil
+	}
+	names := contextNames(config)
+	sort.Strings(names)
+	r := newTableRenderer(out, &GetOptions{})
+	r.header("CURRENT\tNAME\tEDGECORE CONFIG\tDATABASE\tCRI ENDPOINT")
+	for _, name := range names {
+		context := config.Contexts[name]
+		current := ""
+		if name == config.CurrentContext {
+			current = "*"
+		}
+		r.row(fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
+			current, name, context.EdgecoreConfig, contextListDatabase(context), context.CRIEndpoint))
+	}
+	return r.flush()
+}
+
+// contextListDatabase renders the database column, showing a split
+// device database next to the meta one
+func contextListDatabase(context *DebugContext) string {
+	if context.DeviceDatabase != "" && context.DeviceDatabase != context.Database {
+		return context.Database + " (devices: " + context.DeviceDatabase + ")"
+	}
+	return context.Database
+}
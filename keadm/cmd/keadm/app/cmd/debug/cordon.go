@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager"
+)
+
+var cordonSyncLongDescription = `
+    Pause applying cloud updates on the running edgecore, so the state
+    under live debugging cannot change mid-investigation. Updates arriving
+    while cordoned are buffered and replayed in order by uncordon-sync;
+    messages originating on the node itself keep flowing.
+
+    The debug socket is disabled by default; set "debugServerSocket" in
+    the edgecore configuration to enable it.
+`
+
+// CordonSyncOptions has the cordon-sync subcommand information filled by CLI
+type CordonSyncOptions struct {
+	Socket  string
+	Timeout int64
+}
+
+// NewCordonSyncOptions returns cordon-sync options with defaults
+func NewCordonSyncOptions() *CordonSyncOptions {
+	return &CordonSyncOptions{
+		Socket:  debugserver.DefaultSocket,
+		Timeout: 30,
+	}
+}
+
+// addCordonSyncFlags registers the flags shared by cordon-sync and uncordon-sync
+func addCordonSyncFlags(cmd *cobra.Command, opts *CordonSyncOptions) {
+	cmd.Flags().StringVar(&opts.Socket, "socket", opts.Socket,
+		"Path of the edgecore debug socket")
+	cmd.Flags().Int64Var(&opts.Timeout, "timeout", opts.Timeout,
+		"Seconds to wait for the metamanager response")
+}
+
+// NewCordonSync returns the cobra command pausing cloud updates
+func NewCordonSync(out io.Writer) *cobra.Command {
+	opts := NewCordonSyncOptions()
+	cmd := &cobra.Command{
+		Use:   "cordon-sync",
+		Short: "Pause applying cloud updates while debugging the node",
+		Long:  cordonSyncLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCordonSync(out, opts, metamanager.OperationCordon)
+		},
+	}
+	addCordonSyncFlags(cmd, opts)
+	return cmd
+}
+
+// NewUncordonSync returns the cobra command resuming cloud updates
+func NewUncordonSync(out io.Writer) *cobra.Command {
+	opts := NewCordonSyncOptions()
+	cmd := &cobra.Command{
+		Use:   "uncordon-sync",
+		Short: "Resume applying cloud updates and replay what was buffered",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunCordonSync(out, opts, metamanager.OperationUncordon)
+		},
+	}
+	addCordonSyncFlags(cmd, opts)
+	return cmd
+}
+
+// RunCordonSync asks the metamanager of the running edgecore to pause or
+// resume applying cloud updates
+func RunCordonSync(out io.Writer, opts *CordonSyncOptions, operation string) error {
+	if err := requireOperator("debug " + operation); err != nil {
+		return err
+	}
+	message := model.NewMessage("").
+		BuildRouter("keadm", "", "sync", operation)
+	response, err := sendDebugRequest(opts.Socket, &debugserver.Request{
+		Op:             debugserver.OpInject,
+		Module:         metamanager.MetaManagerModuleName,
+		Sync:           true,
+		TimeoutSeconds: opts.Timeout,
+		Message:        message,
+	})
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.Errorf("metamanager failed to %s: %s", operation, response.Error)
+	}
+	if response.Response != nil {
+		fmt.Fprintf(out, "%v\n", response.Response.GetContent())
+	}
+	return nil
+}
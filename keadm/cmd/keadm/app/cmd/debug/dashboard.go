@@ -0,0 +1,251 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var dashboardLongDescription = `
+    Serve a small read-only web dashboard on localhost showing node
+    health, pods, devices and sync status from the local edge database,
+    for site technicians who do not use CLIs. Access needs the token the
+    command prints on startup, pass it once as ?token= and a cookie keeps
+    the session.
+`
+
+// DashboardOptions has the dashboard subcommand information filled by CLI
+type DashboardOptions struct {
+	Bind           string
+	Port           int
+	Token          string
+	EdgecoreConfig string
+	Input          string
+}
+
+// NewDashboard returns the cobra command serving the local dashboard
+func NewDashboard(out io.Writer) *cobra.Command {
+	opts := &DashboardOptions{
+		Bind:           "127.0.0.1",
+		Port:           10455,
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+	cmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Serve a read-only web dashboard of this edge node",
+		Long:  dashboardLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDashboard(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Bind, "bind", opts.Bind,
+		"Address to listen on; keep it on localhost unless the network is trusted")
+	cmd.Flags().IntVar(&opts.Port, "port", opts.Port,
+		"Port to listen on")
+	cmd.Flags().StringVar(&opts.Token, "token", opts.Token,
+		"Access token; a random one is generated and printed when empty")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	return cmd
+}
+
+// RunDashboard serves the dashboard until the process is stopped
+func RunDashboard(out io.Writer, opts *DashboardOptions) error {
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	token := opts.Token
+	if token == "" {
+		raw := make([]byte, 16)
+		if _, err := rand.Read(raw); err != nil {
+			return err
+		}
+		token = hex.EncodeToString(raw)
+	}
+	address := fmt.Sprintf("%s:%d", opts.Bind, opts.Port)
+	fmt.Fprintf(out, T("Dashboard listening on http://%s/?token=%s\n"), address, token)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", dashboardAuth(token, http.HandlerFunc(serveDashboard)))
+	return http.ListenAndServe(address, mux)
+}
+
+// dashboardAuth admits requests presenting the token as a query
+// parameter or the cookie set after the first visit
+func dashboardAuth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented := r.URL.Query().Get("token")
+		if presented == "" {
+			if cookie, err := r.Cookie("keadm-dashboard"); err == nil {
+				presented = cookie.Value
+			}
+		}
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			http.Error(w, "missing or wrong token, open the URL keadm printed", http.StatusUnauthorized)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "keadm-dashboard", Value: presented, HttpOnly: true})
+		next.ServeHTTP(w, r)
+	})
+}
+
+// dashboardPod is one pod row of the page
+type dashboardPod struct {
+	Namespace string
+	Name      string
+	Ready     string
+	Status    string
+	LastSync  string
+}
+
+// dashboardCount is one object count row of the page
+type dashboardCount struct {
+	Type  string
+	Count int
+}
+
+// dashboardData is everything the template renders
+type dashboardData struct {
+	NodeName   string
+	NodeReady  string
+	Heartbeat  string
+	Pods       []dashboardPod
+	Counts     []dashboardCount
+	LoadErrors []string
+}
+
+// serveDashboard renders the single page from the local database
+func serveDashboard(w http.ResponseWriter, r *http.Request) {
+	data := collectDashboardData()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// collectDashboardData assembles the page from the cached objects
+func collectDashboardData() *dashboardData {
+	data := &dashboardData{NodeName: "<unknown>", NodeReady: "unknown", Heartbeat: "no lease cached"}
+	if metas, err := dao.QueryMeta("type", "node"); err == nil && len(*metas) > 0 {
+		node := v1.Node{}
+		if err := json.Unmarshal([]byte((*metas)[0]), &node); err == nil {
+			data.NodeName = node.Name
+			data.NodeReady = "NotReady"
+			for _, condition := range node.Status.Conditions {
+				if condition.Type == v1.NodeReady && condition.Status == v1.ConditionTrue {
+					data.NodeReady = "Ready"
+				}
+			}
+		}
+	}
+	if renewed, err := latestLeaseRenewal(); err == nil && renewed != "" {
+		data.Heartbeat = renewed
+	}
+
+	if metas, err := dao.QueryAllMeta("type", "pod"); err != nil {
+		data.LoadErrors = append(data.LoadErrors, err.Error())
+	} else {
+		for i := range *metas {
+			meta := &(*metas)[i]
+			row := dashboardPod{Namespace: keyNamespace(meta.Key), Name: keyName(meta.Key), LastSync: timestampAge(meta.LastSync)}
+			if pod, err := MetaToPod(meta); err == nil {
+				row.Ready, _ = getReadyAndRestartCount(pod)
+				row.Status = podDisplayStatus(pod)
+			} else {
+				row.Status = "undecodable"
+			}
+			data.Pods = append(data.Pods, row)
+		}
+		sort.Slice(data.Pods, func(i, j int) bool {
+			return data.Pods[i].Namespace+data.Pods[i].Name < data.Pods[j].Namespace+data.Pods[j].Name
+		})
+	}
+
+	if metas, err := dao.QueryMetaKeyTypes(); err != nil {
+		data.LoadErrors = append(data.LoadErrors, err.Error())
+	} else {
+		counts := map[string]int{}
+		for _, meta := range *metas {
+			counts[meta.Type]++
+		}
+		for metaType, count := range counts {
+			data.Counts = append(data.Counts, dashboardCount{Type: metaType, Count: count})
+		}
+		sort.Slice(data.Counts, func(i, j int) bool { return data.Counts[i].Type < data.Counts[j].Type })
+	}
+	return data
+}
+
+// latestLeaseRenewal returns the most recent heartbeat renewal as an age
+func latestLeaseRenewal() (string, error) {
+	metas, err := dao.QueryAllMeta("type", "lease")
+	if err != nil {
+		return "", err
+	}
+	var latest *time.Time
+	for _, meta := range *metas {
+		lease := coordinationv1.Lease{}
+		if err := json.Unmarshal([]byte(meta.Value), &lease); err != nil || lease.Spec.RenewTime == nil {
+			continue
+		}
+		if latest == nil || lease.Spec.RenewTime.Time.After(*latest) {
+			latest = &lease.Spec.RenewTime.Time
+		}
+	}
+	if latest == nil {
+		return "", nil
+	}
+	return fmt.Sprintf("renewed %s ago", humanDuration(time.Since(*latest))), nil
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html><head><title>KubeEdge node {{.NodeName}}</title><style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.8em; text-align: left; }
+.err { color: #a00; }
+</style></head><body>
+<h1>Node {{.NodeName}}: {{.NodeReady}}</h1>
+<p>Heartbeat: {{.Heartbeat}}</p>
+{{range .LoadErrors}}<p class="err">{{.}}</p>{{end}}
+<h2>Pods</h2>
+<table><tr><th>Namespace</th><th>Name</th><th>Ready</th><th>Status</th><th>Last sync</th></tr>
+{{range .Pods}}<tr><td>{{.Namespace}}</td><td>{{.Name}}</td><td>{{.Ready}}</td><td>{{.Status}}</td><td>{{.LastSync}}</td></tr>{{end}}
+</table>
+<h2>Cached objects</h2>
+<table><tr><th>Type</th><th>Objects</th></tr>
+{{range .Counts}}<tr><td>{{.Type}}</td><td>{{.Count}}</td></tr>{{end}}
+</table>
+</body></html>
+`))
@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var (
+	dbCompressLongDescription = `
+    Rewrite the stored object values of the edge database through the
+    compression codec, shrinking rows written before compression was
+    turned on in the edgecore configuration. Stop edgecore before running
+    this against its live database.
+`
+	dbCompressExample = `
+    # Compress the existing rows of the configured database
+    keadm debug db compress
+
+    # Compress a copied database snapshot
+    keadm debug db compress --input /tmp/edgecore.db
+`
+)
+
+// DBOptions has the database maintenance subcommand information filled by CLI
+type DBOptions struct {
+	EdgecoreConfig   string
+	Input            string
+	CheckPermissions bool
+}
+
+// NewDBOptions returns database maintenance options with defaults
+func NewDBOptions() *DBOptions {
+	return &DBOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+}
+
+// addDBFlags registers the flags shared by the database maintenance subcommands
+func addDBFlags(cmd *cobra.Command, opts *DBOptions) {
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	cmd.Flags().BoolVar(&opts.CheckPermissions, "check-permissions", opts.CheckPermissions,
+		"Only verify access to the paths the command needs and report the results")
+}
+
+// NewDB returns the cobra command grouping database maintenance subcommands
+func NewDB(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Maintain the edge metadata database",
+	}
+	cmd.AddCommand(NewDBCompress(out))
+	cmd.AddCommand(NewDBBackfillTimestamps(out))
+	cmd.AddCommand(NewDBMigrate(out))
+	cmd.AddCommand(NewDBDedupe(out))
+	cmd.AddCommand(NewDBStats(out))
+	return cmd
+}
+
+// NewDBBackfillTimestamps returns the cobra command backfilling the
+// created_at/updated_at columns of rows written by older edgecores
+func NewDBBackfillTimestamps(out io.Writer) *cobra.Command {
+	opts := NewDBOptions()
+	cmd := &cobra.Command{
+		Use:   "backfill-timestamps",
+		Short: "Backfill created/updated timestamps on existing database rows",
+		Long: `
+    Fill the created_at/updated_at columns of rows written before the
+    columns existed, taking the creation time from the stored object's
+    metadata.creationTimestamp where available.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDBBackfillTimestamps(out, opts)
+		},
+	}
+	addDBFlags(cmd, opts)
+	return cmd
+}
+
+// RunDBBackfillTimestamps backfills the timestamp columns
+func RunDBBackfillTimestamps(out io.Writer, opts *DBOptions) error {
+	if opts.CheckPermissions {
+		return RunCheckPermissions(out, opts.EdgecoreConfig, opts.Input)
+	}
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	rewritten, err := dao.BackfillTimestamps()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, T("Backfilled %d rows\n"), rewritten)
+	return nil
+}
+
+// NewDBCompress returns the cobra command compressing existing rows
+func NewDBCompress(out io.Writer) *cobra.Command {
+	opts := NewDBOptions()
+	cmd := &cobra.Command{
+		Use:     "compress",
+		Short:   "Compress the stored values of existing database rows",
+		Long:    dbCompressLongDescription,
+		Example: dbCompressExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDBCompress(out, opts)
+		},
+	}
+	addDBFlags(cmd, opts)
+	return cmd
+}
+
+// RunDBCompress rewrites the existing rows through the compression codec
+func RunDBCompress(out io.Writer, opts *DBOptions) error {
+	if opts.CheckPermissions {
+		return RunCheckPermissions(out, opts.EdgecoreConfig, opts.Input)
+	}
+	// rewriting rows under a live edgecore would race its writes
+	if opts.Input == "" && edgecoreServiceRunning() {
+		return errors.Errorf("edgecore is running, stop it first (%s) before rewriting its database", stopEdgecoreHint)
+	}
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	dao.SetValueCompression(true)
+	rewritten, err := dao.CompressExistingRows()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, T("Compressed %d rows\n"), rewritten)
+	return nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var dbDedupeLongDescription = `
+    Find meta rows describing the same object more than once: rows whose
+    namespace and name match while the type disagrees, typically an alias
+    spelling left behind by an interrupted sync. By default the duplicates
+    are only reported; --resolve deletes the older rows, keeping the one
+    written most recently. Stop edgecore before resolving against its live
+    database.
+`
+
+var dbDedupeExample = `
+    # Report duplicate rows
+    keadm debug db dedupe
+
+    # Resolve them in a snapshot, keeping the newest value
+    keadm debug db dedupe --resolve --input /tmp/edgecore.db
+`
+
+// DBDedupeOptions has the db dedupe subcommand information filled by CLI
+type DBDedupeOptions struct {
+	EdgecoreConfig string
+	Input          string
+	Resolve        bool
+}
+
+// duplicateGroup is one object described by more than one meta row
+type duplicateGroup struct {
+	identity string
+	rows     []dao.Meta
+}
+
+// NewDBDedupe returns the cobra command finding and resolving duplicates
+func NewDBDedupe(out io.Writer) *cobra.Command {
+	opts := &DBDedupeOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+	cmd := &cobra.Command{
+		Use:     "dedupe",
+		Short:   "Find meta rows describing the same object twice, optionally resolving them",
+		Long:    dbDedupeLongDescription,
+		Example: dbDedupeExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDBDedupe(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	cmd.Flags().BoolVar(&opts.Resolve, "resolve", opts.Resolve,
+		"Delete the older duplicates, keeping the row written most recently")
+	return cmd
+}
+
+// RunDBDedupe reports the duplicate groups and optionally resolves them
+func RunDBDedupe(out io.Writer, opts *DBDedupeOptions) error {
+	if opts.Resolve {
+		if err := requireOperator("debug db dedupe --resolve"); err != nil {
+			return err
+		}
+		// deleting rows under a live edgecore would race its writes
+		if opts.Input == "" && edgecoreServiceRunning() {
+			return errors.Errorf("edgecore is running, stop it first (%s) before resolving its database", stopEdgecoreHint)
+		}
+	}
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+
+	groups, err := findDuplicateGroups()
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		fmt.Fprintf(out, T("No duplicate rows found\n"))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("OBJECT\tKEY\tUPDATED\tACTION"))
+	resolved := 0
+	for _, group := range groups {
+		keep := newestDuplicate(group.rows)
+		for i := range group.rows {
+			row := &group.rows[i]
+			action := "delete"
+			if row.Key == keep {
+				action = "keep"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", group.identity, row.Key, rowUpdated(row), action)
+			if !opts.Resolve || row.Key == keep {
+				continue
+			}
+			if _, err := dbm.DBAccess.QueryTable(dao.MetaTableName).Filter("key", row.Key).Delete(); err != nil {
+				return errors.Wrapf(err, "failed to delete %s", row.Key)
+			}
+			resolved++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if opts.Resolve {
+		fmt.Fprintf(out, T("Resolved %d duplicate rows\n"), resolved)
+		return nil
+	}
+	fmt.Fprintf(out, T("%d objects have duplicate rows, re-run with --resolve to keep the newest of each\n"), len(groups))
+	return nil
+}
+
+// findDuplicateGroups groups the meta rows by object identity, the type
+// normalized through the command line aliases so pod and pods collide
+func findDuplicateGroups() ([]duplicateGroup, error) {
+	var metas []dao.Meta
+	if _, err := dbm.DBAccess.QueryTable(dao.MetaTableName).All(&metas); err != nil {
+		return nil, errors.Wrap(err, "failed to query the edge database")
+	}
+	byIdentity := map[string][]dao.Meta{}
+	for _, meta := range metas {
+		byIdentity[rowIdentity(&meta)] = append(byIdentity[rowIdentity(&meta)], meta)
+	}
+	var groups []duplicateGroup
+	for identity, rows := range byIdentity {
+		if len(rows) < 2 {
+			continue
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].Key < rows[j].Key })
+		groups = append(groups, duplicateGroup{identity: identity, rows: rows})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].identity < groups[j].identity })
+	return groups, nil
+}
+
+// rowIdentity is the object a meta row describes, independent of the
+// type spelling the row was written under
+func rowIdentity(meta *dao.Meta) string {
+	resType := strings.ToLower(meta.Type)
+	if canonical, ok := resourceAliases[resType]; ok {
+		resType = canonical
+	}
+	return keyNamespace(meta.Key) + "/" + resType + "/" + keyName(meta.Key)
+}
+
+// newestDuplicate picks the key of the row written most recently, ties
+// broken towards the row whose key carries the canonical type spelling
+func newestDuplicate(rows []dao.Meta) string {
+	keep := rows[0].Key
+	newest := ""
+	for i := range rows {
+		row := &rows[i]
+		updated := rowUpdated(row)
+		if updated > newest {
+			newest = updated
+			keep = row.Key
+			continue
+		}
+		if updated == newest && strings.Contains(row.Key, "/"+rowType(row)+"/") {
+			keep = row.Key
+		}
+	}
+	return keep
+}
+
+// rowUpdated is the best write timestamp the row carries, RFC3339 so the
+// string comparison orders correctly; rows from old nodes have none
+func rowUpdated(meta *dao.Meta) string {
+	if meta.UpdatedAt != "" {
+		return meta.UpdatedAt
+	}
+	return meta.LastSync
+}
+
+// rowType is the canonical type of the row
+func rowType(meta *dao.Meta) string {
+	resType := strings.ToLower(meta.Type)
+	if canonical, ok := resourceAliases[resType]; ok {
+		return canonical
+	}
+	return resType
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var dbMigrateLongDescription = `
+    Upgrade the schema of the edge database explicitly, instead of relying
+    on the implicit ORM sync at edgecore start. The migrations are ordered
+    and recorded in the database (PRAGMA user_version), so running migrate
+    again applies only what is missing. --dry-run prints the statements a
+    run would execute, --rollback undoes the most recent migration. Stop
+    edgecore before migrating its live database.
+`
+
+var dbMigrateExample = `
+    # Show what would change
+    keadm debug db migrate --dry-run
+
+    # Upgrade a copied snapshot
+    keadm debug db migrate --input /tmp/edgecore.db
+
+    # Undo the most recent migration
+    keadm debug db migrate --rollback
+`
+
+// schemaMigration is one ordered schema change with its inverse. A
+// migration whose effect the implicit ORM sync already produced is
+// detected by alreadyApplied and only stamped
+type schemaMigration struct {
+	version     int
+	description string
+	up          []string
+	down        []string
+	// alreadyApplied, when set, tells whether the effect of up is
+	// already in place so the version is recorded without executing
+	alreadyApplied func(db *sql.DB) bool
+}
+
+// schemaMigrations is the ordered schema history of the edge database.
+// Append only: released versions keep their number forever
+var schemaMigrations = []schemaMigration{
+	{
+		version:     1,
+		description: "meta diagnostic timestamp columns (last_sync, created_at, updated_at)",
+		up: []string{
+			`ALTER TABLE meta ADD COLUMN last_sync varchar(64)`,
+			`ALTER TABLE meta ADD COLUMN created_at varchar(64)`,
+			`ALTER TABLE meta ADD COLUMN updated_at varchar(64)`,
+		},
+		down: []string{
+			`CREATE TABLE meta_migrate (key varchar(256) NOT NULL PRIMARY KEY, type varchar(32) NOT NULL, value text)`,
+			`INSERT INTO meta_migrate SELECT key, type, value FROM meta`,
+			`DROP TABLE meta`,
+			`ALTER TABLE meta_migrate RENAME TO meta`,
+		},
+		alreadyApplied: func(db *sql.DB) bool {
+			return columnExists(db, "meta", "last_sync")
+		},
+	},
+	{
+		version:     2,
+		description: "index on meta.type speeding the per type listings",
+		up:          []string{`CREATE INDEX IF NOT EXISTS idx_meta_type ON meta (type)`},
+		down:        []string{`DROP INDEX IF EXISTS idx_meta_type`},
+	},
+	{
+		version:     3,
+		description: "index on device_twin.deviceid speeding the per device twin lookups",
+		up:          []string{`CREATE INDEX IF NOT EXISTS idx_device_twin_deviceid ON device_twin (deviceid)`},
+		down:        []string{`DROP INDEX IF EXISTS idx_device_twin_deviceid`},
+	},
+}
+
+// DBMigrateOptions has the db migrate subcommand information filled by CLI
+type DBMigrateOptions struct {
+	EdgecoreConfig string
+	Input          string
+	DryRun         bool
+	Rollback       bool
+}
+
+// NewDBMigrate returns the cobra command migrating the database schema
+func NewDBMigrate(out io.Writer) *cobra.Command {
+	opts := &DBMigrateOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+	cmd := &cobra.Command{
+		Use:     "migrate",
+		Short:   "Upgrade the edge database schema explicitly, with dry-run and rollback",
+		Long:    dbMigrateLongDescription,
+		Example: dbMigrateExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDBMigrate(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", opts.DryRun,
+		"Print the statements a run would execute without touching the database")
+	cmd.Flags().BoolVar(&opts.Rollback, "rollback", opts.Rollback,
+		"Undo the most recent migration instead of upgrading")
+	return cmd
+}
+
+// RunDBMigrate applies the pending migrations, or rolls the last one back
+func RunDBMigrate(out io.Writer, opts *DBMigrateOptions) error {
+	if !opts.DryRun {
+		if err := requireOperator("debug db migrate"); err != nil {
+			return err
+		}
+		// rewriting the schema under a live edgecore would race its writes
+		if opts.Input == "" && edgecoreServiceRunning() {
+			return errors.Errorf("edgecore is running, stop it first (%s) before migrating its database", stopEdgecoreHint)
+		}
+	}
+	db, err := openMigrationDB(opts.EdgecoreConfig, opts.Input)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if opts.Rollback {
+		return rollbackMigration(out, db, current, opts.DryRun)
+	}
+	return applyMigrations(out, db, current, opts.DryRun)
+}
+
+// applyMigrations runs every migration newer than the current version
+func applyMigrations(out io.Writer, db *sql.DB, current int, dryRun bool) error {
+	applied := 0
+	for _, migration := range schemaMigrations {
+		if migration.version <= current {
+			continue
+		}
+		if dryRun {
+			fmt.Fprintf(out, T("Would apply %d: %s\n"), migration.version, migration.description)
+			for _, statement := range migration.up {
+				fmt.Fprintf(out, "    %s\n", statement)
+			}
+			continue
+		}
+		if migration.alreadyApplied != nil && migration.alreadyApplied(db) {
+			fmt.Fprintf(out, T("Recording %d as already applied: %s\n"), migration.version, migration.description)
+		} else {
+			fmt.Fprintf(out, T("Applying %d: %s\n"), migration.version, migration.description)
+			if err := execStatements(db, migration.up); err != nil {
+				return errors.Wrapf(err, "migration %d failed, the schema version stays at %d", migration.version, current)
+			}
+		}
+		if err := setSchemaVersion(db, migration.version); err != nil {
+			return err
+		}
+		current = migration.version
+		applied++
+	}
+	if applied == 0 && !dryRun {
+		fmt.Fprintf(out, T("The schema is up to date at version %d\n"), current)
+	}
+	return nil
+}
+
+// rollbackMigration undoes the migration matching the current version
+func rollbackMigration(out io.Writer, db *sql.DB, current int, dryRun bool) error {
+	if current == 0 {
+		return errors.New("the schema version is 0, nothing to roll back")
+	}
+	for _, migration := range schemaMigrations {
+		if migration.version != current {
+			continue
+		}
+		if dryRun {
+			fmt.Fprintf(out, T("Would roll back %d: %s\n"), migration.version, migration.description)
+			for _, statement := range migration.down {
+				fmt.Fprintf(out, "    %s\n", statement)
+			}
+			return nil
+		}
+		fmt.Fprintf(out, T("Rolling back %d: %s\n"), migration.version, migration.description)
+		if err := execStatements(db, migration.down); err != nil {
+			return errors.Wrapf(err, "rollback of migration %d failed", migration.version)
+		}
+		return setSchemaVersion(db, migration.version-1)
+	}
+	return errors.Errorf("the schema version %d has no known migration, this keadm is older than the database", current)
+}
+
+// openMigrationDB opens the database directly, the migrations speak SQL
+// the ORM layer has no words for
+func openMigrationDB(configPath, input string) (*sql.DB, error) {
+	dataSource := input
+	if config, err := LoadEdgecoreConfig(configPath); err == nil {
+		if dataSource == "" {
+			dataSource = config.DataBase.DataSource
+		}
+	} else if dataSource == "" {
+		return nil, err
+	}
+	if err := checkReadable(dataSource); err != nil {
+		return nil, errors.Wrap(err, "cannot open the edge database")
+	}
+	db, err := sql.Open("sqlite3", dataSource)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open the edge database")
+	}
+	return db, nil
+}
+
+// execStatements runs the statements of one migration inside a transaction
+func execStatements(db *sql.DB, statements []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			tx.Rollback()
+			return errors.Wrapf(err, "%s", statement)
+		}
+	}
+	return tx.Commit()
+}
+
+// schemaVersion reads the recorded schema version of the database
+func schemaVersion(db *sql.DB) (int, error) {
+	version := 0
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, errors.Wrap(err, "failed to read the schema version")
+	}
+	return version, nil
+}
+
+// setSchemaVersion records the schema version, PRAGMA takes no arguments
+func setSchemaVersion(db *sql.DB, version int) error {
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return errors.Wrap(err, "failed to record the schema version")
+	}
+	return nil
+}
+
+// columnExists tells whether the table already has the column
+func columnExists(db *sql.DB, table, column string) bool {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid int
+		var name, columnType string
+		var notNull, primaryKey int
+		var defaultValue interface{}
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &primaryKey); err != nil {
+			continue
+		}
+		if name == column {
+			return true
+		}
+	}
+	return false
+}
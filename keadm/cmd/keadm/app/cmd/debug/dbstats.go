@@ -0,0 +1,213 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var dbStatsLongDescription = `
+    Summarize the edge database: row counts and value sizes per type, the
+    largest stored objects, the file and WAL sizes, free pages and the
+    indexes present. Large averages suggest turning on value compression,
+    many free pages suggest a vacuum, and a WAL rivaling the database
+    itself means no checkpoint ran for a long time.
+`
+
+// dbStatsTopObjects is how many of the largest objects are listed
+const dbStatsTopObjects = 5
+
+// DBStatsOptions has the db stats subcommand information filled by CLI
+type DBStatsOptions struct {
+	EdgecoreConfig string
+	Input          string
+}
+
+// typeStats aggregates the rows of one stored type
+type typeStats struct {
+	resType   string
+	rows      int
+	totalSize int64
+	maxSize   int64
+}
+
+// NewDBStats returns the cobra command summarizing the database
+func NewDBStats(out io.Writer) *cobra.Command {
+	opts := &DBStatsOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Summarize row counts, value sizes and file health of the edge database",
+		Long:  dbStatsLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunDBStats(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	return cmd
+}
+
+// RunDBStats prints the database summary
+func RunDBStats(out io.Writer, opts *DBStatsOptions) error {
+	db, err := openMigrationDB(opts.EdgecoreConfig, opts.Input)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := printTypeStats(out, db); err != nil {
+		return err
+	}
+	if err := printLargestObjects(out, db); err != nil {
+		return err
+	}
+	printFileStats(out, db, opts)
+	return printIndexStats(out, db)
+}
+
+// printTypeStats prints the per type row counts and value sizes
+func printTypeStats(out io.Writer, db *sql.DB) error {
+	rows, err := db.Query("SELECT type, COUNT(*), IFNULL(SUM(LENGTH(value)), 0), IFNULL(MAX(LENGTH(value)), 0) FROM meta GROUP BY type")
+	if err != nil {
+		return errors.Wrap(err, "failed to query the meta table")
+	}
+	defer rows.Close()
+	var stats []typeStats
+	for rows.Next() {
+		var s typeStats
+		if err := rows.Scan(&s.resType, &s.rows, &s.totalSize, &s.maxSize); err != nil {
+			return err
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].totalSize > stats[j].totalSize })
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("TYPE\tROWS\tTOTAL\tAVG\tMAX"))
+	for _, s := range stats {
+		avg := int64(0)
+		if s.rows > 0 {
+			avg = s.totalSize / int64(s.rows)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n",
+			s.resType, s.rows, humanBytes(s.totalSize), humanBytes(avg), humanBytes(s.maxSize))
+	}
+	return w.Flush()
+}
+
+// printLargestObjects prints the biggest stored values, the first
+// candidates for pruning
+func printLargestObjects(out io.Writer, db *sql.DB) error {
+	rows, err := db.Query("SELECT key, LENGTH(value) FROM meta ORDER BY LENGTH(value) DESC LIMIT ?", dbStatsTopObjects)
+	if err != nil {
+		return errors.Wrap(err, "failed to query the meta table")
+	}
+	defer rows.Close()
+	fmt.Fprintf(out, T("\nLargest objects:\n"))
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	for rows.Next() {
+		var key string
+		var size int64
+		if err := rows.Scan(&key, &size); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "    %s\t%s\n", key, humanBytes(size))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// printFileStats prints the sizes of the database file and its WAL plus
+// the free page count a vacuum would reclaim
+func printFileStats(out io.Writer, db *sql.DB, opts *DBStatsOptions) {
+	path := opts.Input
+	if path == "" {
+		if config, err := LoadEdgecoreConfig(opts.EdgecoreConfig); err == nil {
+			path = config.DataBase.DataSource
+		}
+	}
+	fmt.Fprintln(out)
+	if info, err := os.Stat(path); err == nil {
+		fmt.Fprintf(out, T("Database:   %s (%s)\n"), path, humanBytes(info.Size()))
+	}
+	if info, err := os.Stat(path + "-wal"); err == nil {
+		fmt.Fprintf(out, T("WAL:        %s\n"), humanBytes(info.Size()))
+	}
+	freePages, pageSize := 0, 0
+	if err := db.QueryRow("PRAGMA freelist_count").Scan(&freePages); err == nil && freePages > 0 {
+		if err := db.QueryRow("PRAGMA page_size").Scan(&pageSize); err == nil {
+			fmt.Fprintf(out, T("Free pages: %d (%s reclaimable with VACUUM)\n"),
+				freePages, humanBytes(int64(freePages)*int64(pageSize)))
+		}
+	}
+}
+
+// printIndexStats lists the indexes of the user tables, a table scanned
+// without one shows up here as missing
+func printIndexStats(out io.Writer, db *sql.DB) error {
+	rows, err := db.Query("SELECT name, tbl_name FROM sqlite_master WHERE type='index' AND name NOT LIKE 'sqlite_%' ORDER BY tbl_name, name")
+	if err != nil {
+		return errors.Wrap(err, "failed to list the indexes")
+	}
+	defer rows.Close()
+	fmt.Fprintf(out, T("\nIndexes:\n"))
+	listed := 0
+	for rows.Next() {
+		var name, table string
+		if err := rows.Scan(&name, &table); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "    %s on %s\n", name, table)
+		listed++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if listed == 0 {
+		fmt.Fprintf(out, T("    none beyond the primary keys; keadm debug db migrate adds the listing indexes\n"))
+	}
+	return nil
+}
+
+// humanBytes renders a byte count with a binary unit
+func humanBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMi", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKi", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
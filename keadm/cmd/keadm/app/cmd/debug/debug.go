@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	debugLongDescription = `
+    "keadm debug" provides a set of troubleshooting tools which run directly
+    on an edge node, against the locally running edgecore and its database,
+    without requiring connectivity to the cloud.
+`
+	debugExample = `
+    # Collect a 30s CPU profile from the running edgecore
+    keadm debug pprof cpu
+`
+)
+
+// NewDebug returns the cobra command grouping all edge-side troubleshooting subcommands
+func NewDebug(out io.Writer) *cobra.Command {
+	var lang string
+	var context string
+	cmd := &cobra.Command{
+		Use:     "debug",
+		Short:   "Troubleshoot the edge node and the locally running edgecore",
+		Long:    debugLongDescription,
+		Example: debugExample,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			SetLanguage(lang)
+			return applyContext(cmd, context)
+		},
+	}
+	cmd.PersistentFlags().StringVar(&lang, "lang", "",
+		"Language of the output messages, e.g. en or zh; defaults to the locale environment")
+	cmd.PersistentFlags().StringVar(&context, "context", "",
+		"Named context of the keadm config supplying paths, e.g. a site snapshot; defaults to its current-context")
+
+	cmd.AddCommand(NewPprof(out))
+	cmd.AddCommand(NewMetrics(out))
+	cmd.AddCommand(NewTrace(out))
+	cmd.AddCommand(NewMessage(out))
+	cmd.AddCommand(NewGet(out))
+	cmd.AddCommand(NewApply(out))
+	cmd.AddCommand(NewSync(out))
+	cmd.AddCommand(NewCordonSync(out))
+	cmd.AddCommand(NewUncordonSync(out))
+	cmd.AddCommand(NewSimulate(out))
+	cmd.AddCommand(NewChaos(out))
+	cmd.AddCommand(NewValidate(out))
+	cmd.AddCommand(NewPolicy(out))
+	cmd.AddCommand(NewConflicts(out))
+	cmd.AddCommand(NewRollback(out))
+	cmd.AddCommand(NewBench(out))
+	cmd.AddCommand(NewCheck(out))
+	cmd.AddCommand(NewDB(out))
+	cmd.AddCommand(NewAudit(out))
+	cmd.AddCommand(NewUI(out))
+	cmd.AddCommand(NewDashboard(out))
+	cmd.AddCommand(NewExportMetrics(out))
+	cmd.AddCommand(NewReport(out))
+	cmd.AddCommand(NewTunnel(out))
+	cmd.AddCommand(NewGC(out))
+	cmd.AddCommand(NewDNS(out))
+	cmd.AddCommand(NewProbe(out))
+	cmd.AddCommand(NewHub(out))
+	cmd.AddCommand(NewStats(out))
+	cmd.AddCommand(NewTwin(out))
+	cmd.AddCommand(NewExport(out))
+	cmd.AddCommand(NewContext(out))
+	cmd.AddCommand(NewCollect(out))
+	cmd.AddCommand(NewLogs(out))
+	cmd.AddCommand(NewEvents(out))
+
+	// wrapped last so every subcommand registered above is audited
+	auditWrapCommands(cmd)
+	return cmd
+}
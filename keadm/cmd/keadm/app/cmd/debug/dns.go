@@ -0,0 +1,200 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var dnsLongDescription = `
+    Show the effective DNS configuration of the cached pods: dnsPolicy,
+    dnsConfig, hostAliases and the resolv.conf the sandbox actually uses,
+    next to the resolv.conf of the node. When name resolution breaks this
+    attributes the problem to pod configuration or node configuration at
+    a glance.
+`
+
+var dnsExample = `
+    # Inspect the DNS configuration of every pod in the default namespace
+    keadm debug dns
+
+    # Inspect one pod
+    keadm debug dns nginx -n default
+`
+
+// DNSOptions has the dns subcommand information filled by CLI
+type DNSOptions struct {
+	Namespace      string
+	AllNamespaces  bool
+	EdgecoreConfig string
+	Input          string
+}
+
+// NewDNS returns the cobra command inspecting pod DNS configuration
+func NewDNS(out io.Writer) *cobra.Command {
+	opts := &DNSOptions{
+		Namespace:      "default",
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+	cmd := &cobra.Command{
+		Use:     "dns [pod]",
+		Short:   "Show the effective DNS configuration of cached pods",
+		Long:    dnsLongDescription,
+		Example: dnsExample,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := ""
+			if len(args) == 1 {
+				name = args[0]
+			}
+			return RunDNS(out, opts, name)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace,
+		"Namespace of the pods")
+	cmd.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", opts.AllNamespaces,
+		"Inspect pods across all namespaces")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	return cmd
+}
+
+// RunDNS prints the DNS view of the selected pods
+func RunDNS(out io.Writer, opts *DNSOptions, name string) error {
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	metas, err := dao.QueryAllMeta("type", "pod")
+	if err != nil {
+		return errors.Wrap(err, "failed to query the edge database")
+	}
+	nodeResolv := readResolvConf("/etc/resolv.conf")
+	printed := 0
+	for i := range *metas {
+		meta := &(*metas)[i]
+		if !opts.AllNamespaces && keyNamespace(meta.Key) != opts.Namespace {
+			continue
+		}
+		if name != "" && keyName(meta.Key) != name {
+			continue
+		}
+		pod, err := MetaToPod(meta)
+		if err != nil {
+			fmt.Fprintf(out, "%s: <unable to decode: %v>\n", meta.Key, err)
+			continue
+		}
+		if printed > 0 {
+			fmt.Fprintln(out)
+		}
+		printPodDNS(out, meta.Key, pod, nodeResolv)
+		printed++
+	}
+	if printed == 0 {
+		if name != "" {
+			return errors.Errorf("pod %s is not cached in namespace %s", name, opts.Namespace)
+		}
+		fmt.Fprintf(out, T("No resources found\n"))
+	}
+	return nil
+}
+
+// printPodDNS prints the DNS section of one pod
+func printPodDNS(out io.Writer, key string, pod *v1.Pod, nodeResolv string) {
+	fmt.Fprintf(out, "%s:\n", key)
+	policy := string(pod.Spec.DNSPolicy)
+	if policy == "" {
+		policy = string(v1.DNSClusterFirst)
+	}
+	fmt.Fprintf(out, "  dnsPolicy: %s\n", policy)
+	if pod.Spec.HostNetwork {
+		fmt.Fprintf(out, "  hostNetwork: true, the pod resolves like the node\n")
+	}
+	if config := pod.Spec.DNSConfig; config != nil {
+		if len(config.Nameservers) > 0 {
+			fmt.Fprintf(out, "  dnsConfig nameservers: %s\n", strings.Join(config.Nameservers, ", "))
+		}
+		if len(config.Searches) > 0 {
+			fmt.Fprintf(out, "  dnsConfig searches: %s\n", strings.Join(config.Searches, ", "))
+		}
+	}
+	for _, alias := range pod.Spec.HostAliases {
+		fmt.Fprintf(out, "  hostAlias: %s -> %s\n", strings.Join(alias.Hostnames, ", "), alias.IP)
+	}
+
+	sandboxResolv, err := sandboxResolvConf(keyNamespace(key), keyName(key))
+	switch {
+	case err != nil:
+		fmt.Fprintf(out, "  sandbox resolv.conf: <%v>\n", err)
+	case sandboxResolv == nodeResolv:
+		fmt.Fprintf(out, "  sandbox resolv.conf: same as the node\n")
+	default:
+		fmt.Fprintf(out, "  sandbox resolv.conf:\n")
+		for _, line := range strings.Split(strings.TrimSpace(sandboxResolv), "\n") {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+	if nodeResolv != "" {
+		fmt.Fprintf(out, "  node resolv.conf:\n")
+		for _, line := range strings.Split(strings.TrimSpace(nodeResolv), "\n") {
+			fmt.Fprintf(out, "    %s\n", line)
+		}
+	}
+}
+
+// sandboxResolvConf reads the resolv.conf the sandbox of the pod uses
+func sandboxResolvConf(namespace, name string) (string, error) {
+	ids, err := exec.Command("docker", "ps", "-q",
+		"--filter", "label=io.kubernetes.pod.namespace="+namespace,
+		"--filter", "label=io.kubernetes.pod.name="+name).Output()
+	if err != nil {
+		return "", errors.New("container runtime unavailable")
+	}
+	containers := strings.Fields(string(ids))
+	if len(containers) == 0 {
+		return "", errors.New("pod has no running containers")
+	}
+	path, err := exec.Command("docker", "inspect", "-f", "{{.ResolvConfPath}}", containers[0]).Output()
+	if err != nil {
+		return "", errors.New("failed to inspect the sandbox")
+	}
+	content, err := ioutil.ReadFile(strings.TrimSpace(string(path)))
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// readResolvConf reads a resolv.conf, "" when it cannot be read
+func readResolvConf(path string) string {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(content)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// KeadmOutputVersion is the schema version stamped into every JSON
+// document the debug subcommands emit. The contract for fleet tools
+// parsing the output: within one version existing fields keep their
+// name, type and meaning, new fields may appear at any time, and any
+// change breaking that promise bumps the version. Table output carries
+// no such promise; machine consumers parse JSON and check the version
+const KeadmOutputVersion = "v1alpha1"
+
+// outputEnvelope is the document wrapping a JSON listing
+type outputEnvelope struct {
+	KeadmOutputVersion string      `json:"keadmOutputVersion"`
+	Kind               string      `json:"kind"`
+	Items              interface{} `json:"items"`
+}
+
+// writeEnvelope prints the items wrapped in the versioned envelope
+func writeEnvelope(out io.Writer, kind string, items interface{}) error {
+	data, err := json.MarshalIndent(&outputEnvelope{
+		KeadmOutputVersion: KeadmOutputVersion,
+		Kind:               kind,
+		Items:              items,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, string(data))
+	return nil
+}
+
+// openEnvelope starts a streamed envelope whose items the caller prints
+// one by one, comma separated; closeEnvelope finishes it
+func openEnvelope(out io.Writer, kind string) {
+	fmt.Fprintf(out, "{\n\"keadmOutputVersion\": %q,\n\"kind\": %q,\n\"items\": [", KeadmOutputVersion, kind)
+}
+
+// closeEnvelope finishes a streamed envelope
+func closeEnvelope(out io.Writer) {
+	fmt.Fprintln(out, "\n]\n}")
+}
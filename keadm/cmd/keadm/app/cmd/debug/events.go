@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
+)
+
+var eventsLongDescription = `
+    Print the resource operations flowing through the metamanager of the
+    running edgecore as they happen: every insert, update and delete,
+    with its source module. This is the live counterpart of inspecting
+    the database, showing sync activity instead of its result.
+
+    Without --watch the stream stops after --timeout, giving a snapshot
+    of the current activity. Requires edgecore to run with
+    debugServerSocket set.
+`
+
+var eventsExample = `
+    # Watch all resource operations until interrupted
+    keadm debug events --watch
+
+    # Watch what happens to one pod
+    keadm debug events --watch --resource default/pod/nginx
+
+    # Sample the pod activity of the next 30 seconds
+    keadm debug events --resource pod --timeout 30s
+`
+
+// EventsOptions has the events subcommand information filled by CLI
+type EventsOptions struct {
+	Socket   string
+	Resource string
+	Watch    bool
+	Timeout  time.Duration
+}
+
+// NewEvents returns the cobra command streaming metamanager operations
+func NewEvents(out io.Writer) *cobra.Command {
+	opts := &EventsOptions{
+		Socket:  debugserver.DefaultSocket,
+		Timeout: 10 * time.Second,
+	}
+	cmd := &cobra.Command{
+		Use:     "events",
+		Short:   "Stream the resource operations flowing through edgecore live",
+		Long:    eventsLongDescription,
+		Example: eventsExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunEvents(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Socket, "socket", opts.Socket,
+		"Path of the edgecore debug socket")
+	cmd.Flags().StringVar(&opts.Resource, "resource", opts.Resource,
+		"Only show events of this type, namespace/type prefix or full resource key")
+	cmd.Flags().BoolVarP(&opts.Watch, "watch", "w", opts.Watch,
+		"Keep streaming until interrupted instead of stopping after --timeout")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout,
+		"How long a snapshot without --watch listens")
+	return cmd
+}
+
+// RunEvents subscribes over the debug socket and prints the events
+func RunEvents(out io.Writer, opts *EventsOptions) error {
+	conn, err := net.Dial("unix", opts.Socket)
+	if err != nil {
+		return errors.Wrap(err,
+			"failed to connect to the edgecore debug socket, make sure edgecore is running with debugServerSocket set")
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(&debugserver.Request{
+		Op:       debugserver.OpWatch,
+		Resource: opts.Resource,
+	}); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(conn)
+	var ack debugserver.Response
+	if err := decoder.Decode(&ack); err != nil {
+		return errors.Wrap(err, "failed to read response from the debug socket")
+	}
+	if !ack.Success {
+		return errors.New(ack.Error)
+	}
+
+	if opts.Watch {
+		// an interrupt severs the connection, ending the decode loop below
+		interrupted := make(chan os.Signal, 1)
+		signal.Notify(interrupted, os.Interrupt)
+		defer signal.Stop(interrupted)
+		go func() {
+			<-interrupted
+			conn.Close()
+		}()
+	} else {
+		conn.SetReadDeadline(time.Now().Add(opts.Timeout))
+	}
+
+	fmt.Fprintf(out, "%-30s %-10s %-15s %s\n", T("TIME"), T("OPERATION"), T("SOURCE"), T("RESOURCE"))
+	for {
+		var event debugserver.Event
+		if err := decoder.Decode(&event); err != nil {
+			// the deadline or the interrupt ended the stream
+			return nil
+		}
+		fmt.Fprintf(out, "%-30s %-10s %-15s %s\n", event.Time, event.Operation, event.Source, event.Resource)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var exportMetricsLongDescription = `
+    Write pod phase counts, restart totals and sync staleness of this
+    node as Prometheus textfile metrics, so the node_exporter textfile
+    collector folds edge workload state into existing monitoring without
+    another agent. One-shot by default, --interval keeps writing on a
+    schedule.
+`
+
+var exportMetricsExample = `
+    # Write the metrics once for the node_exporter textfile collector
+    keadm debug export-metrics --textfile-dir /var/lib/node_exporter
+
+    # Keep them fresh every 30 seconds
+    keadm debug export-metrics --textfile-dir /var/lib/node_exporter --interval 30s
+`
+
+// ExportMetricsOptions has the export-metrics subcommand information filled by CLI
+type ExportMetricsOptions struct {
+	TextfileDir    string
+	Interval       time.Duration
+	EdgecoreConfig string
+	Input          string
+}
+
+// NewExportMetrics returns the cobra command writing textfile metrics
+func NewExportMetrics(out io.Writer) *cobra.Command {
+	opts := &ExportMetricsOptions{
+		TextfileDir:    "/var/lib/node_exporter",
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+	cmd := &cobra.Command{
+		Use:     "export-metrics",
+		Short:   "Write workload state as Prometheus textfile metrics",
+		Long:    exportMetricsLongDescription,
+		Example: exportMetricsExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunExportMetrics(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.TextfileDir, "textfile-dir", opts.TextfileDir,
+		"Directory the node_exporter textfile collector watches")
+	cmd.Flags().DurationVar(&opts.Interval, "interval", opts.Interval,
+		"Rewrite the metrics on this schedule, 0 writes once and exits")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	return cmd
+}
+
+// RunExportMetrics writes the metrics once or on the requested schedule
+func RunExportMetrics(out io.Writer, opts *ExportMetricsOptions) error {
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	for {
+		if err := writeTextfileMetrics(opts.TextfileDir); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, T("Wrote %s\n"), filepath.Join(opts.TextfileDir, "kubeedge.prom"))
+		if opts.Interval <= 0 {
+			return nil
+		}
+		time.Sleep(opts.Interval)
+	}
+}
+
+// writeTextfileMetrics renders the metrics and moves them into place
+// atomically, the textfile collector must never read a half-written file
+func writeTextfileMetrics(dir string) error {
+	content, err := renderTextfileMetrics(time.Now())
+	if err != nil {
+		return err
+	}
+	temp, err := ioutil.TempFile(dir, ".kubeedge.prom.")
+	if err != nil {
+		return errors.Wrapf(err, "failed to write into the textfile directory %s", dir)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.WriteString(content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), filepath.Join(dir, "kubeedge.prom"))
+}
+
+// renderTextfileMetrics builds the exposition text from the cached pods
+func renderTextfileMetrics(now time.Time) (string, error) {
+	metas, err := dao.QueryAllMeta("type", "pod")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to query the edge database")
+	}
+	phases := map[string]int{}
+	var restarts int64
+	oldestSync := 0.0
+	undecodable := 0
+	for i := range *metas {
+		meta := &(*metas)[i]
+		pod, err := MetaToPod(meta)
+		if err != nil {
+			undecodable++
+			continue
+		}
+		phases[strings.ToLower(string(pod.Status.Phase))]++
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts += int64(status.RestartCount)
+		}
+		if synced, err := time.Parse(time.RFC3339, meta.LastSync); err == nil {
+			if age := now.Sub(synced).Seconds(); age > oldestSync {
+				oldestSync = age
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# HELP kubeedge_pods Number of pods cached on this edge node by phase.\n")
+	b.WriteString("# TYPE kubeedge_pods gauge\n")
+	names := make([]string, 0, len(phases))
+	for phase := range phases {
+		names = append(names, phase)
+	}
+	sort.Strings(names)
+	for _, phase := range names {
+		fmt.Fprintf(&b, "kubeedge_pods{phase=%q} %d\n", phase, phases[phase])
+	}
+	b.WriteString("# HELP kubeedge_pod_restarts_total Summed container restarts of the cached pods.\n")
+	b.WriteString("# TYPE kubeedge_pod_restarts_total counter\n")
+	fmt.Fprintf(&b, "kubeedge_pod_restarts_total %d\n", restarts)
+	b.WriteString("# HELP kubeedge_pod_sync_staleness_seconds Age of the least recently synced pod row.\n")
+	b.WriteString("# TYPE kubeedge_pod_sync_staleness_seconds gauge\n")
+	fmt.Fprintf(&b, "kubeedge_pod_sync_staleness_seconds %g\n", oldestSync)
+	b.WriteString("# HELP kubeedge_pods_undecodable Cached pod rows that failed to decode.\n")
+	b.WriteString("# TYPE kubeedge_pods_undecodable gauge\n")
+	fmt.Fprintf(&b, "kubeedge_pods_undecodable %d\n", undecodable)
+	return b.String(), nil
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtclient"
+)
+
+var exportTelemetryLongDescription = `
+    Dump the latest reported twin and attribute values of the devices in
+    the twin database as CSV or JSON, for offline analysis. The database
+    only keeps the latest value per property; for a history, export
+    periodically and concatenate.
+`
+
+var exportTelemetryExample = `
+    # Export every device as CSV
+    keadm debug export telemetry -o csv > telemetry.csv
+
+    # Export one device as JSON
+    keadm debug export telemetry --device water-valve -o json
+`
+
+// ExportTelemetryOptions has the export telemetry subcommand information filled by CLI
+type ExportTelemetryOptions struct {
+	Device         string
+	Output         string
+	EdgecoreConfig string
+	Input          string
+}
+
+// telemetryRow is one exported value
+type telemetryRow struct {
+	Device    string `json:"device"`
+	Kind      string `json:"kind"`
+	Property  string `json:"property"`
+	Value     string `json:"value"`
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// NewExport returns the cobra command grouping the export tools
+func NewExport(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export cached edge data for offline analysis",
+	}
+	cmd.AddCommand(NewExportTelemetry(out))
+	return cmd
+}
+
+// NewExportTelemetry returns the cobra command dumping device telemetry
+func NewExportTelemetry(out io.Writer) *cobra.Command {
+	opts := &ExportTelemetryOptions{
+		Output:         "csv",
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+	cmd := &cobra.Command{
+		Use:     "telemetry",
+		Short:   "Dump the latest reported twin and attribute values",
+		Long:    exportTelemetryLongDescription,
+		Example: exportTelemetryExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunExportTelemetry(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Device, "device", opts.Device,
+		"Only export this device, by name or id")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", opts.Output,
+		"Output format; available options are 'csv' and 'json'")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	return cmd
+}
+
+// RunExportTelemetry collects the rows and writes them in the chosen format
+func RunExportTelemetry(out io.Writer, opts *ExportTelemetryOptions) error {
+	switch opts.Output {
+	case "csv", "json":
+	default:
+		return errors.Errorf("invalid output format: %s", opts.Output)
+	}
+	registerDeviceModels()
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+
+	var devices []dtclient.Device
+	if _, err := dbm.DBAccess.QueryTable(dtclient.DeviceTableName).All(&devices); err != nil {
+		return errors.Wrap(err, "failed to query the device table")
+	}
+	var rows []telemetryRow
+	matched := false
+	for i := range devices {
+		device := &devices[i]
+		if opts.Device != "" && device.Name != opts.Device && device.ID != opts.Device {
+			continue
+		}
+		matched = true
+		deviceRows, err := telemetryOfDevice(device)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, deviceRows...)
+	}
+	if opts.Device != "" && !matched {
+		return errors.Errorf("device %s is not in the twin database", opts.Device)
+	}
+
+	if opts.Output == "json" {
+		return writeEnvelope(out, "telemetry", rows)
+	}
+	writer := csv.NewWriter(out)
+	if err := writer.Write([]string{"device", "kind", "property", "value", "timestamp"}); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.Write([]string{row.Device, row.Kind, row.Property, row.Value, row.Timestamp}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// telemetryOfDevice collects the twin and attribute rows of one device
+func telemetryOfDevice(device *dtclient.Device) ([]telemetryRow, error) {
+	name := device.Name
+	if name == "" {
+		name = device.ID
+	}
+	twins, err := deviceTwins(device.ID)
+	if err != nil {
+		return nil, err
+	}
+	var rows []telemetryRow
+	for i := range twins {
+		twin := &twins[i]
+		row := telemetryRow{
+			Device:   name,
+			Kind:     "twin",
+			Property: twin.Name,
+			Value:    twin.Actual,
+		}
+		if t, ok := twinTimestamp(twin.ActualMeta); ok {
+			row.Timestamp = t.Format(time.RFC3339)
+		}
+		rows = append(rows, row)
+	}
+	var attributes []dtclient.DeviceAttr
+	if _, err := dbm.DBAccess.QueryTable(dtclient.DeviceAttrTableName).Filter("deviceid", device.ID).All(&attributes); err != nil {
+		return nil, errors.Wrap(err, "failed to query the device attribute table")
+	}
+	for i := range attributes {
+		attribute := &attributes[i]
+		rows = append(rows, telemetryRow{
+			Device:   name,
+			Kind:     "attribute",
+			Property: attribute.Name,
+			Value:    attribute.Value,
+		})
+	}
+	return rows, nil
+}
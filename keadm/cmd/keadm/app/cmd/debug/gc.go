@@ -0,0 +1,200 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var gcImagesLongDescription = `
+    Remove images the container runtime holds but no pod spec cached in
+    the edge database references. Edged's own image GC only kicks in on
+    disk pressure thresholds, which is often not aggressive enough on
+    small storage devices; this reclaims the space deliberately.
+
+    Images younger than --min-age are kept, as are the references listed
+    in --keep, e.g. infrastructure images pulled for the next rollout.
+`
+
+var gcImagesExample = `
+    # Show what would be removed
+    keadm debug gc images --dry-run
+
+    # Remove unreferenced images older than a day, keeping the pause image
+    keadm debug gc images --min-age 24h --keep k8s.gcr.io/pause:3.1
+`
+
+// GCImagesOptions has the gc images subcommand information filled by CLI
+type GCImagesOptions struct {
+	Keep           []string
+	MinAge         time.Duration
+	DryRun         bool
+	EdgecoreConfig string
+	Input          string
+}
+
+// NewGC returns the cobra command grouping the garbage collection tools
+func NewGC(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Reclaim resources edged's own garbage collection leaves behind",
+	}
+	cmd.AddCommand(NewGCImages(out))
+	return cmd
+}
+
+// NewGCImages returns the cobra command removing unreferenced images
+func NewGCImages(out io.Writer) *cobra.Command {
+	opts := &GCImagesOptions{
+		MinAge:         time.Hour,
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+	cmd := &cobra.Command{
+		Use:     "images",
+		Short:   "Remove images no cached pod spec references",
+		Long:    gcImagesLongDescription,
+		Example: gcImagesExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunGCImages(out, opts)
+		},
+	}
+	cmd.Flags().StringSliceVar(&opts.Keep, "keep", opts.Keep,
+		"Image references to keep even when unreferenced, repeatable")
+	cmd.Flags().DurationVar(&opts.MinAge, "min-age", opts.MinAge,
+		"Only remove images created longer ago than this")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", opts.DryRun,
+		"Only report what would be removed")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	return cmd
+}
+
+// gcImage is one runtime image with what the decision needs
+type gcImage struct {
+	reference string
+	created   time.Time
+}
+
+// RunGCImages removes the unreferenced images the thresholds allow
+func RunGCImages(out io.Writer, opts *GCImagesOptions) error {
+	if !opts.DryRun {
+		if err := requireOperator("debug gc images"); err != nil {
+			return err
+		}
+	}
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	referenced, err := referencedImages()
+	if err != nil {
+		return err
+	}
+	wanted := map[string]bool{}
+	for _, image := range referenced {
+		wanted[image] = true
+		wanted[normalizeImageReference(image)] = true
+	}
+	for _, image := range opts.Keep {
+		wanted[image] = true
+		wanted[normalizeImageReference(image)] = true
+	}
+
+	images, err := listRuntimeImages()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("IMAGE\tRESULT"))
+	failed, removed := 0, 0
+	for _, image := range images {
+		switch {
+		case wanted[image.reference] || wanted[normalizeImageReference(image.reference)]:
+			fmt.Fprintf(w, "%s\t%s\n", image.reference, "referenced")
+		case !image.created.IsZero() && time.Since(image.created) < opts.MinAge:
+			fmt.Fprintf(w, "%s\t%s\n", image.reference, "younger than --min-age")
+		case opts.DryRun:
+			fmt.Fprintf(w, "%s\t%s\n", image.reference, "would remove")
+		default:
+			if output, err := exec.Command("docker", "rmi", image.reference).CombinedOutput(); err != nil {
+				// a container still using the image is not an error of
+				// this command, report and move on
+				fmt.Fprintf(w, "%s\tFAILED: %s\n", image.reference, strings.TrimSpace(string(output)))
+				failed++
+			} else {
+				fmt.Fprintf(w, "%s\t%s\n", image.reference, "removed")
+				removed++
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !opts.DryRun {
+		fmt.Fprintf(out, T("Removed %d images, %d failed\n"), removed, failed)
+	}
+	if failed > 0 {
+		return errors.Errorf("%d images failed to remove", failed)
+	}
+	return nil
+}
+
+// listRuntimeImages lists the runtime images with their creation time
+func listRuntimeImages() ([]gcImage, error) {
+	output, err := exec.Command("docker", "images", "--format", "{{.Repository}}:{{.Tag}}\t{{.CreatedAt}}").Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the images of the container runtime")
+	}
+	var images []gcImage
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 2)
+		image := gcImage{reference: fields[0]}
+		if strings.HasPrefix(image.reference, "<none>") {
+			// dangling layers are edged's business, skip them
+			continue
+		}
+		if len(fields) == 2 {
+			// docker prints e.g. 2020-04-01 10:30:00 +0000 UTC
+			if t, err := time.Parse("2006-01-02 15:04:05 -0700 MST", strings.TrimSpace(fields[1])); err == nil {
+				image.created = t
+			}
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// normalizeImageReference makes bare references comparable with the
+// runtime listing, nginx and nginx:latest are the same image
+func normalizeImageReference(image string) string {
+	if strings.Contains(image, ":") {
+		return strings.TrimSuffix(image, ":latest")
+	}
+	return image
+}
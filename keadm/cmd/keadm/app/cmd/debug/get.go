@@ -0,0 +1,854 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/apis/core/v1/helper/qos"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var (
+	getLongDescription = `
+    List resources cached in the local edge metadata database, the way
+    kubectl get lists them from the API server. This works while the node
+    is disconnected from the cloud and, via --input, also against a copied
+    database snapshot.
+`
+	getExample = `
+    # List all pods cached in the default namespace
+    keadm debug get pod
+
+    # List all pods in all namespaces with node style details
+    keadm debug get pod -A -o wide
+
+    # Print one secret as YAML
+    keadm debug get secret mysecret -n kube-system -o yaml
+
+    # Inspect a database copied from another node
+    keadm debug get pod --input /tmp/edgecore.db
+
+    # Audit everything cached on the node, grouped by namespace
+    keadm debug get all -A
+`
+)
+
+// resourceAliases maps the names and short forms accepted on the command
+// line to the resource type stored in the meta table
+var resourceAliases = map[string]string{
+	"pod": "pod", "pods": "pod", "po": "pod",
+	"node": "node", "nodes": "node", "no": "node",
+	"service": "service", "services": "service", "svc": "service",
+	"secret": "secret", "secrets": "secret",
+	"configmap": "configmap", "configmaps": "configmap", "cm": "configmap",
+	"endpoints": "endpoints", "endpoint": "endpoints", "ep": "endpoints",
+	"lease": "lease", "leases": "lease",
+}
+
+// GetOptions has the get subcommand information filled by CLI
+type GetOptions struct {
+	Namespace         string
+	AllNamespaces     bool
+	Output            string
+	EdgecoreConfig    string
+	Input             string
+	MaxMemory         string
+	CheckPermissions  bool
+	Quiet             bool
+	Since             string
+	SinceTime         string
+	ShowManagedFields bool
+	DecryptWith       string
+	Columns           string
+	MaxColumnWidth    int
+	Diff              bool
+	NameRegex         string
+}
+
+// NewGetOptions returns get options with defaults
+func NewGetOptions() *GetOptions {
+	return &GetOptions{
+		Namespace:      "default",
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+}
+
+// NewGet returns the cobra command listing cached resources from the edge database
+func NewGet(out io.Writer) *cobra.Command {
+	opts := NewGetOptions()
+	cmd := &cobra.Command{
+		Use:     "get <resource> [name]",
+		Short:   "List resources cached in the local edge database",
+		Long:    getLongDescription,
+		Example: getExample,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := ""
+			if len(args) == 2 {
+				name = args[1]
+			}
+			return RunGet(out, opts, args[0], name)
+		},
+	}
+	addGetFlags(cmd, opts)
+	return cmd
+}
+
+// addGetFlags registers the flags shared by the listing subcommands
+func addGetFlags(cmd *cobra.Command, opts *GetOptions) {
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace,
+		"Namespace to list resources from")
+	cmd.Flags().BoolVarP(&opts.AllNamespaces, "all-namespaces", "A", opts.AllNamespaces,
+		"List resources across all namespaces")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", opts.Output,
+		"Output format; available options are 'wide', 'yaml', 'json' and 'clean-yaml'")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one, e.g. a copied snapshot")
+	cmd.Flags().StringVar(&opts.MaxMemory, "max-memory", opts.MaxMemory,
+		"Memory budget for the listing, e.g. 64Mi; larger listings stream in chunks. Defaults to a quarter of the cgroup memory limit")
+	cmd.Flags().BoolVar(&opts.CheckPermissions, "check-permissions", opts.CheckPermissions,
+		"Only verify access to the paths the command needs and report the results")
+	cmd.Flags().BoolVarP(&opts.Quiet, "quiet", "q", opts.Quiet,
+		"Only print resource names, one per line, for composition with xargs")
+	cmd.Flags().StringVar(&opts.Since, "since", opts.Since,
+		"Only show resources created or synced within this duration, e.g. 2h")
+	cmd.Flags().StringVar(&opts.SinceTime, "since-time", opts.SinceTime,
+		"Only show resources created or synced after this RFC3339 time")
+	cmd.Flags().BoolVar(&opts.ShowManagedFields, "show-managed-fields", opts.ShowManagedFields,
+		"Keep metadata.managedFields in the JSON and YAML output")
+	cmd.Flags().StringVar(&opts.DecryptWith, "decrypt-with", opts.DecryptWith,
+		"Decrypt envelope-encrypted secret data with this KMS provider; every decryption is audit logged")
+	cmd.Flags().StringVar(&opts.Columns, "columns", opts.Columns,
+		"Comma separated table columns to print, e.g. NAME,STATUS; others are dropped")
+	cmd.Flags().IntVar(&opts.MaxColumnWidth, "max-column-width", opts.MaxColumnWidth,
+		"Truncate table cells longer than this many characters with an ellipsis, 0 keeps them whole")
+	cmd.Flags().BoolVar(&opts.Diff, "diff", opts.Diff,
+		"For devices, only show twin properties whose desired and reported values differ")
+	cmd.Flags().StringVar(&opts.NameRegex, "name-regex", opts.NameRegex,
+		"Only show resources whose name matches this regular expression")
+}
+
+// RunGet lists the requested resource type from the edge database
+func RunGet(out io.Writer, opts *GetOptions, resource, name string) error {
+	if opts.CheckPermissions {
+		return RunCheckPermissions(out, opts.EdgecoreConfig, opts.Input)
+	}
+	if namespaceAliases[strings.ToLower(resource)] {
+		if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+			return err
+		}
+		return runGetNamespaces(out, opts, name)
+	}
+	if deviceAliases[strings.ToLower(resource)] {
+		// devices live in the twin tables, not the meta table
+		registerDeviceModels()
+		if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+			return err
+		}
+		return runGetDevices(out, opts, name)
+	}
+	if kind, ok := workloadAliases[strings.ToLower(resource)]; ok {
+		if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+			return err
+		}
+		return runGetWorkloads(out, opts, kind, name)
+	}
+	if strings.ToLower(resource) == "all" {
+		if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+			return err
+		}
+		return runGetAll(out, opts, name)
+	}
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	// the database is open already, so an unknown type can be answered
+	// with the types actually cached instead of a bare failure
+	resType, err := normalizeResourceType(resource)
+	if err != nil {
+		return err
+	}
+	switch opts.Output {
+	case "", "wide", "json", "yaml", "clean-yaml":
+	default:
+		return errors.Errorf("invalid output format: %s", opts.Output)
+	}
+
+	cutoff, err := resolveSince(opts)
+	if err != nil {
+		return err
+	}
+	matcher, err := nameMatcher(name, opts.NameRegex)
+	if err != nil {
+		return err
+	}
+	if matcher != nil {
+		// a pattern cannot be pushed down into a key lookup, fetch the
+		// listing and match the name segment of the keys
+		name = ""
+	}
+	budget, err := resolveMemoryBudget(opts.MaxMemory)
+	if err != nil {
+		return err
+	}
+	if budget > 0 && name == "" {
+		namespace := opts.Namespace
+		if opts.AllNamespaces {
+			namespace = ""
+		}
+		// a listing estimated above the budget is streamed chunk by
+		// chunk so diagnostics cannot OOM the node they inspect
+		if size, err := dao.SizeOfMeta(resType, namespace); err == nil && size > budget {
+			return runGetChunked(out, opts, resType, cutoff, matcher)
+		}
+	}
+
+	metas, err := queryMetas(resType, opts, name)
+	if err != nil {
+		return errors.Wrap(err, "failed to query the edge database")
+	}
+	results := filterSince(*metas, cutoff)
+	results = filterNames(results, matcher)
+	if opts.DecryptWith != "" {
+		if err := decryptMetas(results, opts.DecryptWith); err != nil {
+			return err
+		}
+	}
+	if opts.Quiet {
+		// nothing but names, an empty listing prints nothing at all
+		return printQuiet(out, results)
+	}
+	if len(results) == 0 {
+		fmt.Fprintf(out, T("No resources found\n"))
+		return nil
+	}
+
+	switch opts.Output {
+	case "json":
+		return printJSON(out, results, resType, opts)
+	case "yaml", "clean-yaml":
+		return printYAML(out, results, opts)
+	default:
+		return printTable(out, results, resType, opts)
+	}
+}
+
+// getChunkSize is how many rows a streamed chunk fetches at once
+const getChunkSize = 500
+
+// runGetChunked pages through the rows instead of materializing the whole
+// listing, keeping peak memory near one chunk no matter how many objects
+// are cached
+func runGetChunked(out io.Writer, opts *GetOptions, resType string, cutoff time.Time, matcher func(string) bool) error {
+	namespace := opts.Namespace
+	if opts.AllNamespaces {
+		namespace = ""
+	}
+	r := newTableRenderer(out, opts)
+	if opts.Output == "json" && !opts.Quiet {
+		openEnvelope(out, resType)
+	}
+	total := 0
+	for offset := int64(0); ; offset += getChunkSize {
+		metas, err := dao.QueryMetaPage(resType, namespace, getChunkSize, offset)
+		if err != nil {
+			return errors.Wrap(err, "failed to query the edge database")
+		}
+		if len(*metas) == 0 {
+			break
+		}
+		fetched := len(*metas)
+		rows := filterNames(filterSince(*metas, cutoff), matcher)
+		if opts.DecryptWith != "" {
+			if err := decryptMetas(rows, opts.DecryptWith); err != nil {
+				return err
+			}
+		}
+		if opts.Quiet {
+			if err := printQuiet(out, rows); err != nil {
+				return err
+			}
+			total += len(rows)
+			if fetched < getChunkSize {
+				break
+			}
+			continue
+		}
+		switch opts.Output {
+		case "json":
+			for _, meta := range rows {
+				value, err := safeJSONValue(&meta, opts)
+				if err != nil {
+					return err
+				}
+				if total > 0 {
+					fmt.Fprint(out, ",")
+				}
+				fmt.Fprintf(out, "\n%s", value)
+				total++
+			}
+		case "yaml", "clean-yaml":
+			if err := printYAML(out, rows, opts); err != nil {
+				return err
+			}
+			total += len(rows)
+		default:
+			if len(rows) == 0 {
+				break
+			}
+			withHeader := total == 0
+			if resType == "pod" {
+				printPodTable(r, rows, opts, withHeader)
+			} else {
+				printGenericTable(r, rows, opts, withHeader)
+			}
+			// flushing per chunk bounds the tabwriter buffer, at the
+			// cost of column alignment across chunk boundaries
+			if err := r.flush(); err != nil {
+				return err
+			}
+			total += len(rows)
+		}
+		if fetched < getChunkSize {
+			break
+		}
+	}
+	if opts.Output == "json" && !opts.Quiet {
+		closeEnvelope(out)
+	}
+	if total == 0 && opts.Output != "json" && !opts.Quiet {
+		fmt.Fprintf(out, T("No resources found\n"))
+	}
+	return nil
+}
+
+// resolveSince turns --since/--since-time into a cutoff, the zero time
+// when neither is given
+func resolveSince(opts *GetOptions) (time.Time, error) {
+	if opts.Since != "" && opts.SinceTime != "" {
+		return time.Time{}, errors.New("only one of --since and --since-time may be given")
+	}
+	if opts.Since != "" {
+		d, err := time.ParseDuration(opts.Since)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid --since value %s", opts.Since)
+		}
+		return time.Now().Add(-d), nil
+	}
+	if opts.SinceTime != "" {
+		t, err := time.Parse(time.RFC3339, opts.SinceTime)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid --since-time value %s", opts.SinceTime)
+		}
+		return t, nil
+	}
+	return time.Time{}, nil
+}
+
+// nameMatcher turns the name argument and --name-regex into a predicate:
+// a glob when the argument carries pattern characters, a regular
+// expression for --name-regex, nil when the plain key lookups suffice
+func nameMatcher(name, regex string) (func(string) bool, error) {
+	if regex != "" {
+		if name != "" {
+			return nil, errors.New("give either a name pattern or --name-regex, not both")
+		}
+		compiled, err := regexp.Compile(regex)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid --name-regex %s", regex)
+		}
+		return compiled.MatchString, nil
+	}
+	if strings.ContainsAny(name, "*?[") {
+		pattern := name
+		return func(candidate string) bool {
+			matched, err := path.Match(pattern, candidate)
+			return err == nil && matched
+		}, nil
+	}
+	return nil, nil
+}
+
+// filterNames keeps the rows whose name segment the matcher accepts,
+// a nil matcher keeps everything
+func filterNames(metas []dao.Meta, matcher func(string) bool) []dao.Meta {
+	if matcher == nil {
+		return metas
+	}
+	var results []dao.Meta
+	for _, meta := range metas {
+		if matcher(keyName(meta.Key)) {
+			results = append(results, meta)
+		}
+	}
+	return results
+}
+
+// filterSince keeps the rows created or synced at or after the cutoff,
+// a zero cutoff keeps everything
+func filterSince(metas []dao.Meta, cutoff time.Time) []dao.Meta {
+	if cutoff.IsZero() {
+		return metas
+	}
+	var results []dao.Meta
+	for _, meta := range metas {
+		if metaChangedSince(&meta, cutoff) {
+			results = append(results, meta)
+		}
+	}
+	return results
+}
+
+// metaChangedSince tells whether the row was created or last synced at
+// or after the cutoff
+func metaChangedSince(meta *dao.Meta, cutoff time.Time) bool {
+	if t, err := time.Parse(time.RFC3339, meta.LastSync); err == nil && !t.Before(cutoff) {
+		return true
+	}
+	if t := creationTimeOfValue(meta.Value); !t.IsZero() && !t.Before(cutoff) {
+		return true
+	}
+	return false
+}
+
+// normalizeResourceType resolves aliases like po or svc to the stored type
+func normalizeResourceType(resource string) (string, error) {
+	if resType, ok := resourceAliases[strings.ToLower(resource)]; ok {
+		return resType, nil
+	}
+	return "", unknownResourceTypeError(resource)
+}
+
+// unknownResourceTypeError builds the failure for a type this command
+// does not know: the types the database actually holds plus close-match
+// suggestions beat a bare "not available"
+func unknownResourceTypeError(resource string) error {
+	message := fmt.Sprintf("resource type %s is not available", resource)
+	if types := storedResourceTypes(); len(types) > 0 {
+		message += fmt.Sprintf("; the database holds: %s", strings.Join(types, ", "))
+	}
+	if suggestions := suggestResourceTypes(resource); len(suggestions) > 0 {
+		message += fmt.Sprintf(". Did you mean %s?", strings.Join(suggestions, " or "))
+	}
+	return errors.New(message)
+}
+
+// storedResourceTypes lists the distinct types of the meta table, sorted
+func storedResourceTypes() []string {
+	metas, err := dao.QueryMetaKeyTypes()
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var types []string
+	for i := range *metas {
+		if t := (*metas)[i].Type; t != "" && !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// suggestResourceTypes fuzzy-matches the given spelling against the
+// accepted aliases, two edits of slack covers the usual typos
+func suggestResourceTypes(resource string) []string {
+	lowered := strings.ToLower(resource)
+	seen := map[string]bool{}
+	var suggestions []string
+	for alias, resType := range resourceAliases {
+		if seen[resType] {
+			continue
+		}
+		if strings.HasPrefix(alias, lowered) || editDistance(lowered, alias) <= 2 {
+			seen[resType] = true
+			suggestions = append(suggestions, resType)
+		}
+	}
+	sort.Strings(suggestions)
+	return suggestions
+}
+
+// editDistance is the Levenshtein distance between two short spellings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// min3 is the smallest of three ints
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// queryMetas fetches only the rows the namespace and name filters select,
+// pushing them down into the key filters of the SQL query so values never
+// leave the database and get decoded just to be discarded afterwards
+func queryMetas(resType string, opts *GetOptions, name string) (*[]dao.Meta, error) {
+	if name != "" && !opts.AllNamespaces {
+		// namespace, type and name pin down the full key
+		return dao.QueryAllMeta("key", fmt.Sprintf("%s/%s/%s", opts.Namespace, resType, name))
+	}
+	namespace := opts.Namespace
+	if opts.AllNamespaces {
+		namespace = ""
+	}
+	metas, err := dao.QueryMetaPage(resType, namespace, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return metas, nil
+	}
+	// a name across all namespaces cannot be one key lookup, match the
+	// name segment of the fetched keys without touching the values
+	results := make([]dao.Meta, 0, len(*metas))
+	for _, meta := range *metas {
+		if keyName(meta.Key) == name {
+			results = append(results, meta)
+		}
+	}
+	return &results, nil
+}
+
+// keyNamespace returns the namespace segment of a meta key
+func keyNamespace(key string) string {
+	tokens := strings.SplitN(key, "/", 2)
+	return tokens[0]
+}
+
+// keyName returns the name segment of a meta key
+func keyName(key string) string {
+	tokens := strings.Split(key, "/")
+	return tokens[len(tokens)-1]
+}
+
+// printQuiet prints one resource name per line, docker style, so the
+// output pipes straight into xargs
+func printQuiet(out io.Writer, metas []dao.Meta) error {
+	for _, meta := range metas {
+		if _, err := fmt.Fprintln(out, keyName(meta.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outputValue returns the stored value the way the output options want
+// it, by default with metadata.managedFields stripped since it dominates
+// the output size without telling the operator anything
+func outputValue(meta *dao.Meta, opts *GetOptions) string {
+	if opts.Output == "clean-yaml" {
+		return cleanValue(meta.Value)
+	}
+	if opts.ShowManagedFields {
+		return meta.Value
+	}
+	return stripManagedFields(meta.Value)
+}
+
+// stripManagedFields removes metadata.managedFields from a stored value,
+// mirroring what kubectl does by default since v1.21
+func stripManagedFields(value string) string {
+	if !strings.Contains(value, `"managedFields"`) {
+		return value
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &obj); err != nil {
+		return value
+	}
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return value
+	}
+	delete(metadata, "managedFields")
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return value
+	}
+	return string(data)
+}
+
+// safeJSONValue returns the stored value ready for embedding in the
+// envelope: a corrupt stored value is emitted as a string so one bad row
+// cannot make the whole envelope unparsable
+func safeJSONValue(meta *dao.Meta, opts *GetOptions) (string, error) {
+	value := outputValue(meta, opts)
+	if json.Valid([]byte(value)) {
+		return value, nil
+	}
+	quoted, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(quoted), nil
+}
+
+// printJSON prints the stored objects wrapped in the versioned envelope
+func printJSON(out io.Writer, metas []dao.Meta, resType string, opts *GetOptions) error {
+	items := make([]json.RawMessage, 0, len(metas))
+	for i := range metas {
+		value, err := safeJSONValue(&metas[i], opts)
+		if err != nil {
+			return err
+		}
+		items = append(items, json.RawMessage(value))
+	}
+	return writeEnvelope(out, resType, items)
+}
+
+// printYAML prints the stored objects as a stream of YAML documents
+func printYAML(out io.Writer, metas []dao.Meta, opts *GetOptions) error {
+	for i := range metas {
+		data, err := yaml.JSONToYAML([]byte(outputValue(&metas[i], opts)))
+		if err != nil {
+			return errors.Wrapf(err, "failed to convert %s", metas[i].Key)
+		}
+		fmt.Fprintf(out, "---\n%s", string(data))
+	}
+	return nil
+}
+
+// forEachParallel runs fn for every index on a bounded worker pool, one
+// worker per CPU. Callers write into preallocated slots indexed by fn's
+// argument, so the output order matches the input order regardless of
+// which worker finished first
+func forEachParallel(n int, fn func(index int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				fn(index)
+			}
+		}()
+	}
+	for index := 0; index < n; index++ {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// printTable renders the kubectl style table for the resource type
+func printTable(out io.Writer, metas []dao.Meta, resType string, opts *GetOptions) error {
+	r := newTableRenderer(out, opts)
+	switch resType {
+	case "pod":
+		printPodTable(r, metas, opts, true)
+	default:
+		printGenericTable(r, metas, opts, true)
+	}
+	return r.flush()
+}
+
+// printPodTable renders pods with the familiar kubectl columns plus the
+// LAST-SYNC age derived from the metamanager sync metadata
+func printPodTable(r *tableRenderer, metas []dao.Meta, opts *GetOptions, withHeader bool) {
+	if withHeader {
+		header := "NAME\tREADY\tSTATUS\tRESTARTS\tAGE\tLAST-SYNC"
+		if opts.Output == "wide" {
+			header += "\tQOS\tPRIORITY-CLASS\tCREATED\tUPDATED"
+		}
+		if opts.AllNamespaces {
+			header = "NAMESPACE\t" + header
+		}
+		r.header(header)
+	}
+	// decoding dominates large listings, spread it over the CPUs while
+	// keeping the rows in key order
+	pods := make([]*v1.Pod, len(metas))
+	decodeErrs := make([]error, len(metas))
+	forEachParallel(len(metas), func(index int) {
+		pods[index], decodeErrs[index] = MetaToPod(&metas[index])
+	})
+	for i, meta := range metas {
+		if decodeErrs[i] != nil {
+			r.row(fmt.Sprintf("%s\t<unable to decode: %v>", keyName(meta.Key), decodeErrs[i]))
+			continue
+		}
+		pod := pods[i]
+		ready, restarts := getReadyAndRestartCount(pod)
+		age := "<unknown>"
+		if !pod.CreationTimestamp.IsZero() {
+			age = humanDuration(time.Since(pod.CreationTimestamp.Time))
+		}
+		// an object without metadata still has a name in its key
+		if pod.Name == "" {
+			pod.Name = keyName(meta.Key)
+		}
+		row := fmt.Sprintf("%s\t%s\t%s\t%d\t%s\t%s",
+			pod.Name, ready, podDisplayStatus(pod), restarts, age, timestampAge(meta.LastSync))
+		if opts.Output == "wide" {
+			priorityClass := pod.Spec.PriorityClassName
+			if priorityClass == "" {
+				priorityClass = "<none>"
+			}
+			// eviction on a pressured node works through QoS class and
+			// priority, surface both instead of burying them in YAML
+			row += fmt.Sprintf("\t%s\t%s\t%s\t%s",
+				qos.GetPodQOS(pod), priorityClass, timestampAge(meta.CreatedAt), timestampAge(meta.UpdatedAt))
+		}
+		if opts.AllNamespaces {
+			row = keyNamespace(meta.Key) + "\t" + row
+		}
+		r.row(row)
+	}
+}
+
+// printGenericTable renders resource types without a dedicated layout
+func printGenericTable(r *tableRenderer, metas []dao.Meta, opts *GetOptions, withHeader bool) {
+	if withHeader {
+		header := "NAME\tAGE\tLAST-SYNC"
+		if opts.Output == "wide" {
+			header += "\tCREATED\tUPDATED"
+		}
+		if opts.AllNamespaces {
+			header = "NAMESPACE\t" + header
+		}
+		r.header(header)
+	}
+	ages := make([]string, len(metas))
+	forEachParallel(len(metas), func(index int) {
+		ages[index] = ageOfValue(metas[index].Value)
+	})
+	for i, meta := range metas {
+		row := fmt.Sprintf("%s\t%s\t%s", keyName(meta.Key), ages[i], timestampAge(meta.LastSync))
+		if opts.Output == "wide" {
+			row += fmt.Sprintf("\t%s\t%s", timestampAge(meta.CreatedAt), timestampAge(meta.UpdatedAt))
+		}
+		if opts.AllNamespaces {
+			row = keyNamespace(meta.Key) + "\t" + row
+		}
+		r.row(row)
+	}
+}
+
+// creationTimeOfValue extracts the creationTimestamp of a stored object
+// without decoding the full value, the zero time when it has none
+func creationTimeOfValue(value string) time.Time {
+	var partial struct {
+		Metadata struct {
+			CreationTimestamp string `json:"creationTimestamp"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(value), &partial); err == nil && partial.Metadata.CreationTimestamp != "" {
+		if t, err := time.Parse(time.RFC3339, partial.Metadata.CreationTimestamp); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ageOfValue derives the age column from the creationTimestamp of a stored object
+func ageOfValue(value string) string {
+	if t := creationTimeOfValue(value); !t.IsZero() {
+		return humanDuration(time.Since(t))
+	}
+	return "<unknown>"
+}
+
+// MetaToPod decodes the stored value of a pod row
+func MetaToPod(meta *dao.Meta) (*v1.Pod, error) {
+	if strings.TrimSpace(meta.Value) == "" {
+		return nil, errors.New("the stored value is empty")
+	}
+	pod := &v1.Pod{}
+	if err := json.Unmarshal([]byte(meta.Value), pod); err != nil {
+		return nil, err
+	}
+	return pod, nil
+}
+
+// getReadyAndRestartCount summarizes container readiness like kubectl,
+// e.g. 1/2, together with the summed restart count. It tolerates pods
+// without a status, e.g. static pod manifests that never ran
+func getReadyAndRestartCount(pod *v1.Pod) (string, int32) {
+	if pod == nil {
+		return "0/0", 0
+	}
+	ready := 0
+	var restarts int32
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+		restarts += status.RestartCount
+	}
+	return fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)), restarts
+}
+
+// podDisplayStatus is the STATUS cell of a pod. The reason wins over the
+// phase like in kubectl, so an evicted pod shows Evicted instead of
+// Failed; a pod carrying neither, e.g. a bare manifest, shows a marker
+// instead of an empty cell
+func podDisplayStatus(pod *v1.Pod) string {
+	if pod == nil {
+		return "<unknown>"
+	}
+	if pod.Status.Reason != "" {
+		return pod.Status.Reason
+	}
+	if pod.Status.Phase != "" {
+		return string(pod.Status.Phase)
+	}
+	return "<unknown>"
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+// the pod fixtures deliberately miss fields real clusters produce:
+// static pod manifests carry no status at all, evicted pods carry a
+// reason without container statuses, and a corrupt row carries no
+// readable value
+var podFixtures = []dao.Meta{
+	{
+		Key:  "default/pod/running",
+		Type: "pod",
+		Value: `{"metadata":{"name":"running","creationTimestamp":"2020-01-01T00:00:00Z"},` +
+			`"spec":{"containers":[{"name":"app"}]},` +
+			`"status":{"phase":"Running","containerStatuses":[{"name":"app","ready":true,"restartCount":2}]}}`,
+	},
+	{
+		Key:   "default/pod/static",
+		Type:  "pod",
+		Value: `{"metadata":{"name":"static"},"spec":{"hostNetwork":true,"containers":[{"name":"app"}]}}`,
+	},
+	{
+		Key:   "default/pod/evicted",
+		Type:  "pod",
+		Value: `{"metadata":{"name":"evicted"},"spec":{"containers":[{"name":"app"}]},"status":{"phase":"Failed","reason":"Evicted"}}`,
+	},
+	{
+		Key:   "default/pod/nameless",
+		Type:  "pod",
+		Value: `{"spec":{"containers":[{"name":"app"}]}}`,
+	},
+	{
+		Key:   "default/pod/corrupt",
+		Type:  "pod",
+		Value: `{"metadata":`,
+	},
+	{
+		Key:   "default/pod/empty",
+		Type:  "pod",
+		Value: "",
+	},
+}
+
+func TestPrintPodTableIncompleteObjects(t *testing.T) {
+	var out bytes.Buffer
+	if err := printTable(&out, podFixtures, "pod", &GetOptions{}); err != nil {
+		t.Fatalf("printTable failed: %v", err)
+	}
+	table := out.String()
+	for _, want := range []string{
+		"running", "1/1", "Running",
+		// the static pod has no status, the cell falls back to a marker
+		"static", "0/1", "<unknown>",
+		// the reason wins over the Failed phase like in kubectl
+		"evicted", "Evicted",
+		// the name comes from the key when the metadata carries none
+		"nameless",
+		// undecodable rows become a diagnostic row instead of a panic
+		"<unable to decode",
+	} {
+		if !strings.Contains(table, want) {
+			t.Errorf("table misses %q:\n%s", want, table)
+		}
+	}
+}
+
+func TestPrintPodTableWide(t *testing.T) {
+	var out bytes.Buffer
+	opts := &GetOptions{Output: "wide", AllNamespaces: true}
+	if err := printTable(&out, podFixtures, "pod", opts); err != nil {
+		t.Fatalf("printTable failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "default") {
+		t.Errorf("wide table misses the namespace column:\n%s", out.String())
+	}
+}
+
+func TestPrintGenericTableNonObjectValues(t *testing.T) {
+	metas := []dao.Meta{
+		{Key: "default/configmap/plain", Type: "configmap", Value: "not json at all"},
+		{Key: "default/configmap/empty", Type: "configmap", Value: ""},
+	}
+	var out bytes.Buffer
+	if err := printTable(&out, metas, "configmap", &GetOptions{}); err != nil {
+		t.Fatalf("printTable failed: %v", err)
+	}
+	for _, want := range []string{"plain", "empty", "<unknown>"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("table misses %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPrintJSONSurvivesCorruptValues(t *testing.T) {
+	var out bytes.Buffer
+	if err := printJSON(&out, podFixtures, "pod", &GetOptions{}); err != nil {
+		t.Fatalf("printJSON failed: %v", err)
+	}
+	var envelope struct {
+		Version string            `json:"keadmOutputVersion"`
+		Items   []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &envelope); err != nil {
+		t.Fatalf("the envelope is not valid JSON: %v\n%s", err, out.String())
+	}
+	if envelope.Version != KeadmOutputVersion {
+		t.Errorf("envelope version = %q, want %q", envelope.Version, KeadmOutputVersion)
+	}
+	if len(envelope.Items) != len(podFixtures) {
+		t.Errorf("envelope holds %d items, want %d", len(envelope.Items), len(podFixtures))
+	}
+}
+
+func TestChunkedJSONSurvivesCorruptValues(t *testing.T) {
+	// the chunked path pages through the database instead of taking the
+	// rows as an argument, so the fixtures go through a real store
+	dbFile := filepath.Join(t.TempDir(), "edgecore.db")
+	if file, err := os.Create(dbFile); err != nil {
+		t.Fatalf("cannot create the test database: %v", err)
+	} else {
+		file.Close()
+	}
+	if err := InitDB("", dbFile); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+	for i := range podFixtures {
+		if err := dao.SaveMeta(&podFixtures[i]); err != nil {
+			t.Fatalf("SaveMeta %s failed: %v", podFixtures[i].Key, err)
+		}
+	}
+	var out bytes.Buffer
+	opts := &GetOptions{Output: "json", Namespace: "default"}
+	if err := runGetChunked(&out, opts, "pod", time.Time{}, nil); err != nil {
+		t.Fatalf("runGetChunked failed: %v", err)
+	}
+	var envelope struct {
+		Version string            `json:"keadmOutputVersion"`
+		Items   []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &envelope); err != nil {
+		t.Fatalf("the chunked envelope is not valid JSON: %v\n%s", err, out.String())
+	}
+	if len(envelope.Items) != len(podFixtures) {
+		t.Errorf("chunked envelope holds %d items, want %d", len(envelope.Items), len(podFixtures))
+	}
+}
+
+func TestGetReadyAndRestartCountNilPod(t *testing.T) {
+	ready, restarts := getReadyAndRestartCount(nil)
+	if ready != "0/0" || restarts != 0 {
+		t.Errorf("got %s/%d, want 0/0 and 0 restarts", ready, restarts)
+	}
+}
+
+func TestMetaToPodEmptyValue(t *testing.T) {
+	if _, err := MetaToPod(&dao.Meta{Key: "default/pod/empty"}); err == nil {
+		t.Error("expected an error for an empty stored value")
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+// runGetAll lists every cached listable type at once, grouped by
+// namespace with a totals section, so a multi tenant node can be audited
+// in one look instead of one get per type
+func runGetAll(out io.Writer, opts *GetOptions, name string) error {
+	switch opts.Output {
+	case "", "wide":
+	default:
+		return errors.Errorf("get all renders namespace grouped tables; get one resource type for %s output", opts.Output)
+	}
+	cutoff, err := resolveSince(opts)
+	if err != nil {
+		return err
+	}
+	matcher, err := nameMatcher(name, opts.NameRegex)
+	if err != nil {
+		return err
+	}
+	if matcher == nil && name != "" {
+		wanted := name
+		matcher = func(candidate string) bool { return candidate == wanted }
+	}
+
+	// namespace -> type -> rows, in the order the sections print
+	grouped := map[string]map[string][]dao.Meta{}
+	for _, resType := range allListableTypes() {
+		metas, err := queryMetas(resType, opts, "")
+		if err != nil {
+			return errors.Wrap(err, "failed to query the edge database")
+		}
+		rows := filterNames(filterSince(*metas, cutoff), matcher)
+		for _, meta := range rows {
+			namespace := keyNamespace(meta.Key)
+			if grouped[namespace] == nil {
+				grouped[namespace] = map[string][]dao.Meta{}
+			}
+			grouped[namespace][resType] = append(grouped[namespace][resType], meta)
+		}
+	}
+	if len(grouped) == 0 {
+		fmt.Fprintf(out, T("No resources found\n"))
+		return nil
+	}
+
+	namespaces := make([]string, 0, len(grouped))
+	for namespace := range grouped {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+
+	if opts.Quiet {
+		// the full keys keep the lines unambiguous across types
+		for _, namespace := range namespaces {
+			for _, resType := range allListableTypes() {
+				for _, meta := range grouped[namespace][resType] {
+					fmt.Fprintln(out, meta.Key)
+				}
+			}
+		}
+		return nil
+	}
+
+	// the sections repeat the namespace in their header, the rows do not
+	sections := *opts
+	sections.AllNamespaces = false
+	for i, namespace := range namespaces {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, T("NAMESPACE: %s\n"), namespace)
+		for _, resType := range allListableTypes() {
+			rows := grouped[namespace][resType]
+			if len(rows) == 0 {
+				continue
+			}
+			fmt.Fprintf(out, "\n%s\n", resType)
+			if err := printAllSection(out, resType, rows, &sections); err != nil {
+				return err
+			}
+		}
+	}
+	return printAllTotals(out, namespaces, grouped)
+}
+
+// allListableTypes are the canonical types get all walks, the targets of
+// the resource aliases in a stable order
+func allListableTypes() []string {
+	seen := map[string]bool{}
+	var types []string
+	for _, resType := range resourceAliases {
+		if !seen[resType] {
+			seen[resType] = true
+			types = append(types, resType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// printAllSection renders one type inside one namespace group
+func printAllSection(out io.Writer, resType string, metas []dao.Meta, opts *GetOptions) error {
+	r := newTableRenderer(out, opts)
+	if resType == "pod" {
+		printPodTable(r, metas, opts, true)
+	} else {
+		printGenericTable(r, metas, opts, true)
+	}
+	return r.flush()
+}
+
+// printAllTotals closes the listing with one row per namespace and the
+// overall count, the at-a-glance part of the audit
+func printAllTotals(out io.Writer, namespaces []string, grouped map[string]map[string][]dao.Meta) error {
+	fmt.Fprintf(out, "\n%s\n", T("TOTALS"))
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("NAMESPACE\tOBJECTS\tBREAKDOWN"))
+	total := 0
+	for _, namespace := range namespaces {
+		count := 0
+		var breakdown []string
+		for _, resType := range allListableTypes() {
+			if n := len(grouped[namespace][resType]); n > 0 {
+				count += n
+				breakdown = append(breakdown, fmt.Sprintf("%s=%d", resType, n))
+			}
+		}
+		total += count
+		fmt.Fprintf(w, "%s\t%d\t%s\n", namespace, count, strings.Join(breakdown, ", "))
+	}
+	fmt.Fprintf(w, "%s\t%d\t\n", T("TOTAL"), total)
+	return w.Flush()
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/pkg/errors"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtclient"
+)
+
+// deviceAliases are the spellings of the device resource type, which
+// lives in the twin tables instead of the meta table
+var deviceAliases = map[string]bool{
+	"device": true, "devices": true, "dev": true,
+}
+
+// runGetDevices lists devices from the twin database, or with --diff only
+// the twin properties whose desired and reported values differ
+func runGetDevices(out io.Writer, opts *GetOptions, name string) error {
+	var devices []dtclient.Device
+	query := dbm.DBAccess.QueryTable(dtclient.DeviceTableName)
+	if name != "" {
+		query = query.Filter("name", name)
+	}
+	if _, err := query.All(&devices); err != nil {
+		return errors.Wrap(err, "failed to query the device table")
+	}
+	if len(devices) == 0 {
+		if name != "" {
+			return errors.Errorf("device %s is not in the twin database", name)
+		}
+		fmt.Fprintf(out, T("No resources found\n"))
+		return nil
+	}
+	if opts.Diff {
+		return printDeviceDiff(out, opts, devices)
+	}
+
+	r := newTableRenderer(out, opts)
+	r.header("NAME\tSTATE\tTWINS\tLAST TELEMETRY")
+	for i := range devices {
+		device := &devices[i]
+		twins, err := deviceTwins(device.ID)
+		if err != nil {
+			return err
+		}
+		last := "never"
+		if t := newestTwinTimestamp(twins); !t.IsZero() {
+			last = humanDuration(time.Since(t)) + " ago"
+		}
+		displayName := device.Name
+		if displayName == "" {
+			displayName = device.ID
+		}
+		r.row(fmt.Sprintf("%s\t%s\t%d\t%s", displayName, device.State, len(twins), last))
+	}
+	return r.flush()
+}
+
+// printDeviceDiff prints only the twin properties where desired and
+// reported disagree, the diff device engineers reconcile against
+func printDeviceDiff(out io.Writer, opts *GetOptions, devices []dtclient.Device) error {
+	r := newTableRenderer(out, opts)
+	r.header("DEVICE\tPROPERTY\tDESIRED\tDESIRED-AT\tREPORTED\tREPORTED-AT")
+	differing := 0
+	for i := range devices {
+		device := &devices[i]
+		twins, err := deviceTwins(device.ID)
+		if err != nil {
+			return err
+		}
+		displayName := device.Name
+		if displayName == "" {
+			displayName = device.ID
+		}
+		for j := range twins {
+			twin := &twins[j]
+			if twin.Expected == twin.Actual {
+				continue
+			}
+			differing++
+			r.row(fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s",
+				displayName, twin.Name,
+				twinValue(twin.Expected), twinTimestampColumn(twin.ExpectedMeta),
+				twinValue(twin.Actual), twinTimestampColumn(twin.ActualMeta)))
+		}
+	}
+	if differing == 0 {
+		fmt.Fprintf(out, T("All twin properties are in sync\n"))
+		return nil
+	}
+	return r.flush()
+}
+
+// deviceTwins fetches the twin rows of one device
+func deviceTwins(deviceID string) ([]dtclient.DeviceTwin, error) {
+	var twins []dtclient.DeviceTwin
+	if _, err := dbm.DBAccess.QueryTable(dtclient.DeviceTwinTableName).Filter("deviceid", deviceID).All(&twins); err != nil {
+		return nil, errors.Wrap(err, "failed to query the device twin table")
+	}
+	return twins, nil
+}
+
+// newestTwinTimestamp is the most recent reported timestamp of the twins
+func newestTwinTimestamp(twins []dtclient.DeviceTwin) time.Time {
+	var newest time.Time
+	for i := range twins {
+		if t, ok := twinTimestamp(twins[i].ActualMeta); ok && t.After(newest) {
+			newest = t
+		}
+	}
+	return newest
+}
+
+// twinValue renders a twin value cell, empty means never set
+func twinValue(value string) string {
+	if value == "" {
+		return "<unset>"
+	}
+	return value
+}
+
+// twinTimestampColumn renders the timestamp of a twin metadata document
+func twinTimestampColumn(metadata string) string {
+	if t, ok := twinTimestamp(metadata); ok {
+		return t.Format(time.RFC3339)
+	}
+	return "-"
+}
+
+// registerDeviceModels makes the twin tables queryable, must run before
+// InitDB bootstraps the orm
+func registerDeviceModels() {
+	orm.RegisterModel(new(dtclient.Device))
+	orm.RegisterModel(new(dtclient.DeviceTwin))
+	orm.RegisterModel(new(dtclient.DeviceAttr))
+}
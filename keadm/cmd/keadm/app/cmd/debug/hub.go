@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub"
+)
+
+var hubStatusLongDescription = `
+    Show the connection statistics edgehub persists: whether the hub is
+    connected and for how long, the reconnect count, the last disconnect
+    reason, recent connection uptimes and the message counters. This is
+    the first question of every flapping-node ticket, answered from the
+    node itself, even while edgecore is down.
+`
+
+// HubStatusOptions has the hub status subcommand information filled by CLI
+type HubStatusOptions struct {
+	StatusFile string
+}
+
+// NewHub returns the cobra command grouping the edgehub inspection tools
+func NewHub(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "hub",
+		Short: "Inspect the edgehub connection to cloudcore",
+	}
+	cmd.AddCommand(NewHubStatus(out))
+	return cmd
+}
+
+// NewHubStatus returns the cobra command printing the connection statistics
+func NewHubStatus(out io.Writer) *cobra.Command {
+	opts := &HubStatusOptions{
+		StatusFile: edgehub.HubStatusPath,
+	}
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the connection statistics edgehub persists",
+		Long:  hubStatusLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunHubStatus(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.StatusFile, "status-file", opts.StatusFile,
+		"Path of the persisted status, e.g. from a collected node archive")
+	return cmd
+}
+
+// RunHubStatus prints the persisted connection statistics
+func RunHubStatus(out io.Writer, opts *HubStatusOptions) error {
+	data, err := ioutil.ReadFile(opts.StatusFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.Errorf("no status at %s, this edgecore has not connected since the statistics were introduced", opts.StatusFile)
+		}
+		return errors.Wrap(err, "failed to read the persisted status")
+	}
+	var status edgehub.HubStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return errors.Wrapf(err, "the status at %s is not readable", opts.StatusFile)
+	}
+
+	if status.Connected {
+		fmt.Fprintf(out, T("Connected:        yes, since %s (up %s)\n"),
+			status.ConnectedSince.Format(time.RFC3339), hubDuration(time.Since(status.ConnectedSince)))
+	} else {
+		fmt.Fprintf(out, T("Connected:        no\n"))
+	}
+	fmt.Fprintf(out, T("Reconnects:       %d\n"), status.Reconnects)
+	if !status.LastDisconnectTime.IsZero() {
+		fmt.Fprintf(out, T("Last disconnect:  %s, %s\n"),
+			status.LastDisconnectTime.Format(time.RFC3339), status.LastDisconnectReason)
+	}
+	fmt.Fprintf(out, T("Messages:         %d to the cloud, %d from the cloud, %d awaiting a response\n"),
+		status.MessagesToCloud, status.MessagesFromCloud, status.PendingSyncMessages)
+	// a connected status that stopped updating means edgecore died
+	// without closing the entry
+	if status.Connected && time.Since(status.UpdatedAt) > 2*time.Minute {
+		fmt.Fprintf(out, T("Warning: the status was last updated %s ago, edgecore may no longer be running\n"),
+			hubDuration(time.Since(status.UpdatedAt)))
+	}
+
+	if len(status.History) > 0 {
+		fmt.Fprintln(out)
+		w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+		fmt.Fprintln(w, T("CONNECTED\tUPTIME\tDISCONNECT REASON"))
+		for _, connection := range status.History {
+			fmt.Fprintf(w, "%s\t%s\t%s\n",
+				connection.ConnectedAt.Format(time.RFC3339),
+				hubDuration(connection.DisconnectedAt.Sub(connection.ConnectedAt)),
+				connection.DisconnectReason)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hubDuration renders a duration without sub-second noise
+func hubDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
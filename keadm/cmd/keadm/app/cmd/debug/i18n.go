@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"os"
+	"strings"
+)
+
+// messageCatalog holds the translated output strings of the debug
+// subcommands keyed by language and by the English format string, so the
+// English text in the code stays the single source of truth. Unknown
+// strings and unknown languages fall back to English. The zh catalog
+// covers the table headers and the short result notices; the longer
+// prose diagnostics stay English until someone translates them
+var messageCatalog = map[string]map[string]string{
+	"zh": {
+		// table headers
+		"PATH\tUSED FOR\tRESULT":                                     "路径\t用途\t结果",
+		"METRIC\tVALUE\tTHRESHOLD\tRESULT":                           "指标\t数值\t阈值\t结果",
+		"METRIC\tVALUE\tDELTA":                                       "指标\t数值\t增量",
+		"METRIC\tVALUE":                                              "指标\t数值",
+		"NAME\tRENEWED\tDURATION\tRESULT":                            "名称\t续约时间\t时长\t结果",
+		"NAMESPACE\tTYPE\tNAME\tLOCAL-VERSION\tCLOUD-VERSION\tNEWER": "命名空间\t类型\t名称\t本地版本\t云端版本\t较新方",
+		"TIME\tEVENT\tMODULE\tSOURCE\tOPERATION\tRESOURCE\tLATENCY":  "时间\t事件\t模块\t来源\t操作\t资源\t延迟",
+		"KEY\tTYPE\tBACKUP-TIME":                                     "键\t类型\t备份时间",
+		"DEVICE\tSTATE\tLAST TELEMETRY\tRESULT":                      "设备\t状态\t最近遥测\t结果",
+		"POD\tVOLUME\tTYPE\tRESULT\tDETAIL":                          "POD\t卷\t类型\t结果\t详情",
+		"NODE\tRESULT\tBUNDLE":                                       "节点\t结果\t归档包",
+		"OBJECT\tKEY\tUPDATED\tACTION":                               "对象\t键\t更新时间\t处理",
+		"TYPE\tROWS\tTOTAL\tAVG\tMAX":                                "类型\t行数\t总计\t平均\t最大",
+		"IMAGE\tRESULT":                                              "镜像\t结果",
+		"NAMESPACE\tOBJECTS\tBREAKDOWN":                              "命名空间\t对象数\t明细",
+		"CONNECTED\tUPTIME\tDISCONNECT REASON":                       "已连接\t在线时长\t断连原因",
+		"NAME\tOBJECTS":                                              "名称\t对象数",
+		"RULE\tRESULT\tREASON":                                       "规则\t结果\t原因",
+		"TRANSPORT\tSERVER\tHANDSHAKES\tLOSS\tMIN\tAVG\tMAX":         "传输\t服务器\t握手\t丢失\t最小\t平均\t最大",
+		"POD\tBEFORE\tAFTER\tRESULT":                                 "POD\t之前\t之后\t结果",
+		"MODULE\tIN\tOUT\tAVG DISPATCH\tERRORS":                      "模块\t接收\t发出\t平均分发\t错误",
+		"CHECK\tRESULT\tDETAIL":                                      "检查项\t结果\t详情",
+		"TIME":                                                       "时间",
+		"OPERATION":                                                  "操作",
+		"SOURCE":                                                     "来源",
+		"RESOURCE":                                                   "资源",
+		"TOTALS":                                                     "总计",
+		"TOTAL":                                                      "合计",
+		// notices
+		"No resources found\n":                            "未找到资源\n",
+		"No conflicts found, the cache matches the cloud": "未发现冲突，缓存与云端一致",
+		"No backups found":                                "未找到备份",
+		"No duplicate rows found\n":                       "未发现重复行\n",
+		"NAMESPACE: %s\n":                                 "命名空间：%s\n",
+		"Compressed %d rows\n":                            "已压缩 %d 行\n",
+		"Backfilled %d rows\n":                            "已回填 %d 行\n",
+		"%d of %d permission checks failed":               "%d/%d 项权限检查未通过",
+		"Synced %d resources to %s\n":                     "已同步 %d 个资源到 %s\n",
+		"Rolled back %s to its previous value\n":          "已将 %s 回滚到之前的值\n",
+		"Message dispatched to module %s\n":               "消息已分发到模块 %s\n",
+		"Collecting CPU profile for %ds ...\n":            "正在采集 CPU 性能分析，共 %ds ...\n",
+		"Profile written to %s\n":                         "性能分析文件已写入 %s\n",
+		"All twin properties are in sync\n":               "所有孪生属性均已同步\n",
+		"Wrote %s\n":                                      "已写入 %s\n",
+		"Wrote %s (%d bytes)\n":                           "已写入 %s（%d 字节）\n",
+		"Removed %d images, %d failed\n":                  "已删除 %d 个镜像，%d 个失败\n",
+		"Resolved %d duplicate rows\n":                    "已解决 %d 个重复行\n",
+		"Collected at %s\n":                               "采集于 %s\n",
+		"Closed tunnel port %d\n":                         "已关闭隧道端口 %d\n",
+	},
+}
+
+// language is the catalog T reads from, chosen from the environment at
+// startup and overridable with --lang
+var language = detectLanguage()
+
+// detectLanguage derives the output language from the locale environment
+// variables, e.g. LANG=zh_CN.UTF-8 selects zh
+func detectLanguage() string {
+	for _, name := range []string{"LANGUAGE", "LC_ALL", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		lang := strings.SplitN(value, ".", 2)[0]
+		lang = strings.SplitN(lang, "_", 2)[0]
+		if lang != "" {
+			return lang
+		}
+	}
+	return "en"
+}
+
+// SetLanguage overrides the detected output language, empty keeps it
+func SetLanguage(lang string) {
+	if lang != "" {
+		language = lang
+	}
+}
+
+// T translates an output format string into the selected language,
+// passing it through untouched when no translation exists
+func T(format string) string {
+	if translated, ok := messageCatalog[language][format]; ok {
+		return translated
+	}
+	return format
+}
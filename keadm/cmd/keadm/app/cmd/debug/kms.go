@@ -0,0 +1,185 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+// kmsEnvelopePrefix marks a secret data value as envelope-encrypted:
+// kms:v1:<keyID>:<base64 ciphertext>
+const kmsEnvelopePrefix = "kms:v1:"
+
+// kmsKeyDir is where the localkey provider looks up its data encryption keys
+const kmsKeyDir = "/etc/kubeedge/kms"
+
+// KMSProvider decrypts envelope-encrypted secret values. Additional
+// providers register themselves in an init function, the way the dbm
+// drivers do
+type KMSProvider interface {
+	// Name is the identifier given to --decrypt-with
+	Name() string
+	// Decrypt returns the plaintext of one envelope ciphertext
+	Decrypt(keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// kmsProviders holds the registered providers by name
+var kmsProviders = map[string]KMSProvider{}
+
+// RegisterKMSProvider makes a provider available to --decrypt-with
+func RegisterKMSProvider(provider KMSProvider) {
+	kmsProviders[provider.Name()] = provider
+}
+
+// kmsProvider resolves a provider name
+func kmsProvider(name string) (KMSProvider, error) {
+	provider, ok := kmsProviders[name]
+	if !ok {
+		names := make([]string, 0, len(kmsProviders))
+		for name := range kmsProviders {
+			names = append(names, name)
+		}
+		return nil, errors.Errorf("unknown KMS provider %s, available: %s", name, strings.Join(names, ", "))
+	}
+	return provider, nil
+}
+
+func init() {
+	RegisterKMSProvider(localKeyProvider{})
+}
+
+// localKeyProvider decrypts with AES-GCM data encryption keys held in
+// files on the node, the keyID of the envelope names the key file
+type localKeyProvider struct{}
+
+// Name implements KMSProvider
+func (localKeyProvider) Name() string {
+	return "localkey"
+}
+
+// Decrypt implements KMSProvider, the ciphertext is nonce||sealed
+func (localKeyProvider) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	key, err := ioutil.ReadFile(filepath.Join(kmsKeyDir, keyID+".key"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read the data encryption key %s", keyID)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid data encryption key %s", keyID)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext shorter than the nonce")
+	}
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "decryption failed, wrong key or corrupt ciphertext")
+	}
+	return plaintext, nil
+}
+
+// decryptSecretValue decrypts the envelope-encrypted data entries of a
+// stored secret and moves the plaintext to stringData for display. Every
+// decrypted field is audit logged. Entries that are not enveloped pass
+// through untouched
+func decryptSecretValue(meta *dao.Meta, provider KMSProvider) (string, error) {
+	var secret map[string]interface{}
+	if err := json.Unmarshal([]byte(meta.Value), &secret); err != nil {
+		return "", errors.Wrapf(err, "failed to decode secret %s", meta.Key)
+	}
+	data, ok := secret["data"].(map[string]interface{})
+	if !ok {
+		return meta.Value, nil
+	}
+	stringData, _ := secret["stringData"].(map[string]interface{})
+	for field, entry := range data {
+		encoded, ok := entry.(string)
+		if !ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || !strings.HasPrefix(string(raw), kmsEnvelopePrefix) {
+			continue
+		}
+		// kms:v1:<keyID>:<base64 ciphertext>
+		parts := strings.SplitN(string(raw), ":", 4)
+		if len(parts) != 4 {
+			return "", errors.Errorf("secret %s field %s has a malformed envelope", meta.Key, field)
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(parts[3])
+		if err != nil {
+			return "", errors.Errorf("secret %s field %s has a malformed envelope", meta.Key, field)
+		}
+		plaintext, err := provider.Decrypt(parts[2], ciphertext)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to decrypt secret %s field %s", meta.Key, field)
+		}
+		if err := auditDecryption(provider.Name(), meta.Key, field); err != nil {
+			return "", errors.Wrap(err, "refusing to decrypt without audit logging")
+		}
+		if stringData == nil {
+			stringData = map[string]interface{}{}
+			secret["stringData"] = stringData
+		}
+		stringData[field] = string(plaintext)
+		delete(data, field)
+	}
+	result, err := json.Marshal(secret)
+	if err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// decryptMetas rewrites the secret rows of a listing through the named
+// KMS provider in place
+func decryptMetas(metas []dao.Meta, providerName string) error {
+	provider, err := kmsProvider(providerName)
+	if err != nil {
+		return err
+	}
+	for i := range metas {
+		if metas[i].Type != "secret" {
+			continue
+		}
+		value, err := decryptSecretValue(&metas[i], provider)
+		if err != nil {
+			return err
+		}
+		metas[i].Value = value
+	}
+	return nil
+}
+
+// auditDecryption appends one line per decrypted field to the audit log
+func auditDecryption(provider, secretKey, field string) error {
+	return auditAppend(fmt.Sprintf("provider=%s secret=%s field=%s", provider, secretKey, field))
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// metaDiagnosticColumns are the meta columns newer releases added on top
+// of the original key/type/value layout. Snapshots from older nodes miss
+// them, and the ORM selects them unconditionally
+var metaDiagnosticColumns = []string{"last_sync", "created_at", "updated_at"}
+
+// ensureCompatibleLayout detects a legacy database layout and makes it
+// readable for the ORM, which selects the newer columns unconditionally.
+// The inspected database is never touched: the missing nullable columns
+// are added to a temporary copy and the returned dataSource points the
+// caller at the copy. Rows from before the columns existed simply render
+// their ages as <unknown>; keadm debug db migrate upgrades in place
+func ensureCompatibleLayout(dataSource string) (string, error) {
+	db, err := sql.Open("sqlite3", dataSource)
+	if err != nil {
+		return dataSource, nil
+	}
+	// a database without a meta table is fresh, the ORM sync lays it out
+	// in the current shape
+	if !tableExists(db, "meta") {
+		db.Close()
+		return dataSource, nil
+	}
+	var missing []string
+	for _, column := range metaDiagnosticColumns {
+		if !columnExists(db, "meta", column) {
+			missing = append(missing, column)
+		}
+	}
+	db.Close()
+	if len(missing) == 0 {
+		return dataSource, nil
+	}
+	copied, err := copyToTempDB(dataSource)
+	if err != nil {
+		return dataSource, errors.Wrap(err,
+			"the database has a legacy layout and could not be copied for the upgrade; run keadm debug db migrate to upgrade it in place")
+	}
+	copyDB, err := sql.Open("sqlite3", copied)
+	if err != nil {
+		return dataSource, err
+	}
+	defer copyDB.Close()
+	for _, column := range missing {
+		if _, err := copyDB.Exec(fmt.Sprintf("ALTER TABLE meta ADD COLUMN %s varchar(64)", column)); err != nil {
+			return dataSource, errors.Wrap(err,
+				"the database has a legacy layout and the temporary copy could not be upgraded; run keadm debug db migrate to upgrade it in place")
+		}
+	}
+	fmt.Fprintf(os.Stderr, T("note: legacy database layout detected, reading from a temporary copy with the %s columns added; the original is untouched, writes will not persist, run keadm debug db migrate to upgrade it in place\n"),
+		strings.Join(missing, ", "))
+	return copied, nil
+}
+
+// copyToTempDB copies the database file behind the dataSource into a
+// temporary file and returns its path
+func copyToTempDB(dataSource string) (string, error) {
+	path := strings.TrimPrefix(dataSource, "file:")
+	if at := strings.IndexByte(path, '?'); at >= 0 {
+		path = path[:at]
+	}
+	source, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+	target, err := ioutil.TempFile("", "keadm-legacy-*.db")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		os.Remove(target.Name())
+		return "", err
+	}
+	if err := target.Close(); err != nil {
+		os.Remove(target.Name())
+		return "", err
+	}
+	return target.Name(), nil
+}
+
+// tableExists tells whether the database has the table
+func tableExists(db *sql.DB, table string) bool {
+	name := ""
+	err := db.QueryRow("SELECT name FROM sqlite_master WHERE type='table' AND name=?", table).Scan(&name)
+	return err == nil && name == table
+}
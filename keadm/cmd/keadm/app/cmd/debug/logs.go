@@ -0,0 +1,278 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	internalapi "k8s.io/cri-api/pkg/apis"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+	"k8s.io/kubernetes/pkg/kubelet/remote"
+	kubelettypes "k8s.io/kubernetes/pkg/kubelet/types"
+)
+
+var logsLongDescription = `
+    Print the logs of a container on this node, straight from the
+    container runtime, without going through the cloud API server. The
+    container is resolved through the CRI endpoint, so this works for any
+    runtime edgecore is configured with.
+
+    With --follow the tail survives log file rotation and container
+    restarts: the container is re-resolved periodically, so a crash
+    looping pod keeps streaming across its restarts.
+`
+
+var logsExample = `
+    # Print the logs of the only container of a pod
+    keadm debug logs nginx-deployment-77698bff7d-l9v2x
+
+    # Follow a named container through restarts
+    keadm debug logs -f -n kube-system edge-dns dnsmasq
+
+    # Print the last 50 lines
+    keadm debug logs --tail 50 nginx-deployment-77698bff7d-l9v2x
+`
+
+const (
+	// logsResolvePeriod is how often a followed container is re-resolved,
+	// picking up the replacement after a restart
+	logsResolvePeriod = 2 * time.Second
+
+	// logsPollPeriod is how often a followed log file is checked for new
+	// lines and rotation
+	logsPollPeriod = 500 * time.Millisecond
+)
+
+// LogsOptions has the logs subcommand information filled by CLI
+type LogsOptions struct {
+	Namespace   string
+	CRIEndpoint string
+	Tail        int64
+	Follow      bool
+}
+
+// NewLogs returns the cobra command printing container logs through the CRI
+func NewLogs(out io.Writer) *cobra.Command {
+	opts := &LogsOptions{
+		Namespace:   "default",
+		CRIEndpoint: defaultCRIEndpoint,
+		Tail:        -1,
+	}
+	cmd := &cobra.Command{
+		Use:     "logs <pod> [container]",
+		Short:   "Print the logs of a container through the container runtime",
+		Long:    logsLongDescription,
+		Example: logsExample,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			container := ""
+			if len(args) > 1 {
+				container = args[1]
+			}
+			return RunLogs(out, opts, args[0], container)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace,
+		"Namespace of the pod")
+	cmd.Flags().StringVar(&opts.CRIEndpoint, "cri-endpoint", opts.CRIEndpoint,
+		"Endpoint of the container runtime the container is resolved through")
+	cmd.Flags().Int64Var(&opts.Tail, "tail", opts.Tail,
+		"Lines from the end of the log to show; -1 shows everything")
+	cmd.Flags().BoolVarP(&opts.Follow, "follow", "f", opts.Follow,
+		"Keep streaming the log, across rotations and container restarts")
+	return cmd
+}
+
+// RunLogs resolves the container through the CRI and prints its log file
+func RunLogs(out io.Writer, opts *LogsOptions, pod, container string) error {
+	runtime, err := remote.NewRemoteRuntimeService(opts.CRIEndpoint, 10*time.Second)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reach the container runtime at %s", opts.CRIEndpoint)
+	}
+	id, path, err := resolveLogContainer(runtime, opts.Namespace, pod, container)
+	if err != nil {
+		return err
+	}
+	offset, err := printLogTail(out, path, opts.Tail)
+	if err != nil {
+		return err
+	}
+	if !opts.Follow {
+		return nil
+	}
+	return followLog(out, runtime, opts, pod, container, id, path, offset)
+}
+
+// resolveLogContainer finds the container of the pod through the runtime
+// and returns its id and log file. A crash looping pod leaves exited
+// containers behind, the newest one is the one an operator means
+func resolveLogContainer(runtime internalapi.RuntimeService, namespace, pod, container string) (string, string, error) {
+	selector := map[string]string{
+		kubelettypes.KubernetesPodNameLabel:      pod,
+		kubelettypes.KubernetesPodNamespaceLabel: namespace,
+	}
+	if container != "" {
+		selector[kubelettypes.KubernetesContainerNameLabel] = container
+	}
+	containers, err := runtime.ListContainers(&runtimeapi.ContainerFilter{LabelSelector: selector})
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to list the containers of the runtime")
+	}
+	if len(containers) == 0 {
+		if container != "" {
+			return "", "", errors.Errorf("no container %s of pod %s/%s is known to the runtime", container, namespace, pod)
+		}
+		return "", "", errors.Errorf("no container of pod %s/%s is known to the runtime", namespace, pod)
+	}
+	newest := containers[0]
+	for _, candidate := range containers[1:] {
+		if candidate.CreatedAt > newest.CreatedAt {
+			newest = candidate
+		}
+	}
+	status, err := runtime.ContainerStatus(newest.Id)
+	if err != nil {
+		return "", "", errors.Wrap(err, "failed to inspect the container")
+	}
+	if status.LogPath == "" {
+		return "", "", errors.Errorf("the runtime reports no log file for container %s", newest.Id)
+	}
+	return newest.Id, status.LogPath, nil
+}
+
+// printLogTail prints the last lines of the log file and returns the
+// offset the follow loop continues from
+func printLogTail(out io.Writer, path string, tail int64) (int64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot read the container log file; run this on the edge node itself")
+	}
+	lines := strings.Split(string(data), "\n")
+	if last := len(lines) - 1; last >= 0 && lines[last] == "" {
+		lines = lines[:last]
+	}
+	if tail >= 0 && int64(len(lines)) > tail {
+		lines = lines[int64(len(lines))-tail:]
+	}
+	for _, line := range lines {
+		fmt.Fprintln(out, logLineMessage(line))
+	}
+	return int64(len(data)), nil
+}
+
+// followLog keeps streaming the log file, reopening it when it rotates
+// and switching to the replacement container after a restart
+func followLog(out io.Writer, runtime internalapi.RuntimeService, opts *LogsOptions, pod, container, id, path string, offset int64) error {
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+
+	poll := time.NewTicker(logsPollPeriod)
+	defer poll.Stop()
+	lastResolve := time.Now()
+
+	for {
+		select {
+		case <-interrupted:
+			return nil
+		case <-poll.C:
+		}
+
+		// a restarted container logs into a fresh file, pick it up where
+		// the old one left off
+		if time.Since(lastResolve) >= logsResolvePeriod {
+			lastResolve = time.Now()
+			if newID, newPath, err := resolveLogContainer(runtime, opts.Namespace, pod, container); err == nil && newID != id {
+				id, path, offset = newID, newPath, 0
+			}
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			// between rotation and the first new line the file may be gone
+			continue
+		}
+		if info.Size() < offset {
+			// the file shrank under us, it was rotated in place
+			offset = 0
+		}
+		if info.Size() == offset {
+			continue
+		}
+		next, err := printLogFrom(out, path, offset)
+		if err != nil {
+			continue
+		}
+		offset = next
+	}
+}
+
+// printLogFrom prints the complete lines appended after the offset and
+// returns the offset of the next unprinted byte. A partially written
+// line stays for the next poll
+func printLogFrom(out io.Writer, path string, offset int64) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return offset, err
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+	data, err := ioutil.ReadAll(file)
+	if err != nil && len(data) == 0 {
+		return offset, err
+	}
+	for {
+		at := strings.IndexByte(string(data), '\n')
+		if at < 0 {
+			break
+		}
+		fmt.Fprintln(out, logLineMessage(string(data[:at])))
+		data = data[at+1:]
+		offset += int64(at) + 1
+	}
+	return offset, nil
+}
+
+// logLineMessage strips the runtime framing off one log line. CRI log
+// files prefix every line with a timestamp, the stream and a tag; the
+// docker json-file driver wraps the line in a JSON object instead
+func logLineMessage(line string) string {
+	if strings.HasPrefix(line, "{") {
+		var entry struct {
+			Log string `json:"log"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err == nil && entry.Log != "" {
+			return strings.TrimSuffix(entry.Log, "\n")
+		}
+		return line
+	}
+	if fields := strings.SplitN(line, " ", 4); len(fields) == 4 {
+		return fields[3]
+	}
+	return line
+}
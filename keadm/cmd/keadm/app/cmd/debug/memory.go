@@ -0,0 +1,63 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// resolveMemoryBudget turns the --max-memory value into a byte budget,
+// falling back to a budget derived from the cgroup limits of the node
+func resolveMemoryBudget(value string) (int64, error) {
+	if value == "" {
+		return defaultMemoryBudget(), nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid --max-memory value %s", value)
+	}
+	return quantity.Value(), nil
+}
+
+// defaultMemoryBudget derives the byte budget from the cgroup memory
+// limit, taking a quarter of it so diagnostics leave room for the
+// workloads sharing the node. Zero means no limit could be determined
+// and chunking stays off unless --max-memory is given.
+func defaultMemoryBudget() int64 {
+	for _, path := range []string{
+		// cgroup v2, contains the string max when unlimited
+		"/sys/fs/cgroup/memory.max",
+		// cgroup v1, reports a huge number when unlimited
+		"/sys/fs/cgroup/memory/memory.limit_in_bytes",
+	} {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		limit, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil || limit <= 0 || limit >= int64(1)<<60 {
+			continue
+		}
+		return limit / 4
+	}
+	return 0
+}
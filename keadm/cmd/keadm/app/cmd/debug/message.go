@@ -0,0 +1,208 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
+)
+
+var (
+	messageSendLongDescription = `
+    Inject a crafted message into the beehive framework of the running
+    edgecore through its local debug socket and report how the target
+    module handled it. This gives a controlled way to reproduce sync
+    problems between modules.
+
+    The debug socket is disabled by default; set "debugServerSocket" in
+    the edgecore configuration to enable it.
+`
+	messageSendExample = `
+    # Ask edged to insert a pod as if it came from the cloud
+    keadm debug message send --module edged --operation insert --resource default/pod/nginx -f pod.yaml
+
+    # Fire-and-forget injection without waiting for the module response
+    keadm debug message send --module metamanager --operation delete --resource default/pod/nginx --sync=false
+`
+)
+
+// MessageSendOptions has the message send subcommand information filled by CLI
+type MessageSendOptions struct {
+	Socket    string
+	Module    string
+	Operation string
+	Resource  string
+	File      string
+	Sync      bool
+	Timeout   int64
+}
+
+// NewMessageSendOptions returns message send options with defaults
+func NewMessageSendOptions() *MessageSendOptions {
+	return &MessageSendOptions{
+		Socket:    debugserver.DefaultSocket,
+		Operation: model.InsertOperation,
+		Sync:      true,
+		Timeout:   30,
+	}
+}
+
+// NewMessage returns the cobra command grouping beehive message operations
+func NewMessage(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "message",
+		Short: "Interact with beehive messages of the running edgecore",
+	}
+	cmd.AddCommand(NewMessageSend(out))
+	return cmd
+}
+
+// NewMessageSend returns the cobra command injecting a message into edgecore
+func NewMessageSend(out io.Writer) *cobra.Command {
+	opts := NewMessageSendOptions()
+	cmd := &cobra.Command{
+		Use:     "send",
+		Short:   "Inject a crafted message into the beehive framework",
+		Long:    messageSendLongDescription,
+		Example: messageSendExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunMessageSend(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Socket, "socket", opts.Socket,
+		"Path of the edgecore debug socket")
+	cmd.Flags().StringVar(&opts.Module, "module", opts.Module,
+		"Destination beehive module, e.g. edged, metamanager")
+	cmd.Flags().StringVar(&opts.Operation, "operation", opts.Operation,
+		"Message operation, e.g. insert, update, delete, query")
+	cmd.Flags().StringVar(&opts.Resource, "resource", opts.Resource,
+		"Message resource, e.g. default/pod/nginx; derived from the manifest when omitted")
+	cmd.Flags().StringVarP(&opts.File, "file", "f", opts.File,
+		"Manifest whose content becomes the message body")
+	cmd.Flags().BoolVar(&opts.Sync, "sync", opts.Sync,
+		"Wait for the module response")
+	cmd.Flags().Int64Var(&opts.Timeout, "timeout", opts.Timeout,
+		"Seconds to wait for the module response")
+	return cmd
+}
+
+// RunMessageSend builds the message from the options and injects it
+func RunMessageSend(out io.Writer, opts *MessageSendOptions) error {
+	if err := requireOperator("debug message send"); err != nil {
+		return err
+	}
+	if opts.Module == "" {
+		return errors.New("--module is required")
+	}
+
+	var content string
+	if opts.File != "" {
+		data, err := ioutil.ReadFile(opts.File)
+		if err != nil {
+			return err
+		}
+		jsonData, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse %s", opts.File)
+		}
+		content = string(jsonData)
+		if opts.Resource == "" {
+			opts.Resource = resourceFromManifest(jsonData)
+		}
+	}
+	if opts.Resource == "" {
+		return errors.New("--resource is required when it cannot be derived from the manifest")
+	}
+
+	message := model.NewMessage("").
+		BuildRouter("keadm", "", opts.Resource, opts.Operation).
+		FillBody(content)
+
+	response, err := sendDebugRequest(opts.Socket, &debugserver.Request{
+		Op:             debugserver.OpInject,
+		Module:         opts.Module,
+		Sync:           opts.Sync,
+		TimeoutSeconds: opts.Timeout,
+		Message:        message,
+	})
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.Errorf("module %s failed to handle the message: %s", opts.Module, response.Error)
+	}
+	if response.Response != nil {
+		fmt.Fprintf(out, T("Module %s handled the message, response operation: %s, content: %v\n"),
+			opts.Module, response.Response.GetOperation(), response.Response.GetContent())
+	} else {
+		fmt.Fprintf(out, T("Message dispatched to module %s\n"), opts.Module)
+	}
+	return nil
+}
+
+// sendDebugRequest submits one request over the edgecore debug socket
+func sendDebugRequest(socket string, request *debugserver.Request) (*debugserver.Response, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, errors.Wrap(err,
+			"failed to connect to the edgecore debug socket, make sure edgecore is running with debugServerSocket set")
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return nil, err
+	}
+	var response debugserver.Response
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		return nil, errors.Wrap(err, "failed to read response from the debug socket")
+	}
+	return &response, nil
+}
+
+// resourceFromManifest derives a beehive resource like default/pod/nginx
+// from the metadata of a parsed manifest, returning "" when it cannot
+func resourceFromManifest(jsonData []byte) string {
+	var manifest struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(jsonData, &manifest); err != nil {
+		return ""
+	}
+	if manifest.Kind == "" || manifest.Metadata.Name == "" {
+		return ""
+	}
+	namespace := manifest.Metadata.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	return fmt.Sprintf("%s/%s/%s", namespace, strings.ToLower(manifest.Kind), manifest.Metadata.Name)
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/common/constants"
+)
+
+var (
+	metricsLongDescription = `
+    Fetch the Prometheus metrics served by the locally running edgecore,
+    optionally filtered by module name, and print them as a sorted table.
+
+    With --interval a second sample is taken after the given duration and
+    the difference between the two samples is shown in a DELTA column,
+    which makes counters much easier to interpret.
+`
+	metricsExample = `
+    # Print all edgecore metrics
+    keadm debug metrics
+
+    # Print only edged related metrics
+    keadm debug metrics edged
+
+    # Show how the metrics move over ten seconds
+    keadm debug metrics edgehub --interval 10s
+`
+)
+
+// MetricsOptions has the metrics subcommand information filled by CLI
+type MetricsOptions struct {
+	Port     int32
+	Interval time.Duration
+}
+
+// NewMetricsOptions returns metrics options with the edgecore defaults
+func NewMetricsOptions() *MetricsOptions {
+	return &MetricsOptions{
+		Port: constants.ServerPort,
+	}
+}
+
+// NewMetrics returns the cobra command scraping and printing edgecore metrics
+func NewMetrics(out io.Writer) *cobra.Command {
+	opts := NewMetricsOptions()
+	cmd := &cobra.Command{
+		Use:     "metrics [module]",
+		Short:   "Fetch and pretty-print edgecore metrics",
+		Long:    metricsLongDescription,
+		Example: metricsExample,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			module := ""
+			if len(args) == 1 {
+				module = args[0]
+			}
+			return RunMetrics(out, opts, module)
+		},
+	}
+	cmd.Flags().Int32Var(&opts.Port, "port", opts.Port,
+		"Port of the edgecore metrics server on localhost")
+	cmd.Flags().DurationVar(&opts.Interval, "interval", opts.Interval,
+		"Take a second sample after this duration and print per-metric deltas")
+	return cmd
+}
+
+// RunMetrics scrapes the metrics endpoint once (or twice with --interval) and renders the table
+func RunMetrics(out io.Writer, opts *MetricsOptions, module string) error {
+	first, err := scrapeMetrics(opts.Port, module)
+	if err != nil {
+		return err
+	}
+
+	var second map[string]float64
+	if opts.Interval > 0 {
+		time.Sleep(opts.Interval)
+		second, err = scrapeMetrics(opts.Port, module)
+		if err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(first))
+	for name := range first {
+		names = append(names, name)
+	}
+	for name := range second {
+		if _, ok := first[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return errors.Errorf("no metrics matched module %q", module)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	if second != nil {
+		fmt.Fprintln(w, T("METRIC\tVALUE\tDELTA"))
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t%v\t%+v\n", name, second[name], second[name]-first[name])
+		}
+	} else {
+		fmt.Fprintln(w, T("METRIC\tVALUE"))
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t%v\n", name, first[name])
+		}
+	}
+	return w.Flush()
+}
+
+// scrapeMetrics fetches the Prometheus text exposition from edgecore and
+// returns the samples (keyed by metric name plus labels) matching module
+func scrapeMetrics(port int32, module string) (map[string]float64, error) {
+	url := fmt.Sprintf("http://127.0.0.1:%d/metrics", port)
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to the edgecore metrics server, make sure edgecore is running")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("metrics server returned %s", resp.Status)
+	}
+
+	samples := map[string]float64{}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		idx := strings.LastIndex(line, " ")
+		if idx < 0 {
+			continue
+		}
+		name := line[:idx]
+		if module != "" && !strings.Contains(name, module) {
+			continue
+		}
+		value, err := strconv.ParseFloat(line[idx+1:], 64)
+		if err != nil {
+			continue
+		}
+		samples[name] = value
+	}
+	return samples, scanner.Err()
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+// namespaceAliases maps the command line names of the namespace listing.
+// No namespace object is synced to the edge database, the listing is
+// derived from the namespace segment of the stored keys
+var namespaceAliases = map[string]bool{
+	"namespace": true, "namespaces": true, "ns": true,
+}
+
+// runGetNamespaces enumerates the distinct namespaces present across the
+// stored keys with the number of cached objects in each, so operators
+// can see what exists before narrowing a listing with -n
+func runGetNamespaces(out io.Writer, opts *GetOptions, name string) error {
+	if opts.Output == "json" || opts.Output == "yaml" || opts.Output == "clean-yaml" {
+		return errors.Errorf("namespace listings are derived from the stored keys and have no stored object to print as %s", opts.Output)
+	}
+	metas, err := dao.QueryMetaKeyTypes()
+	if err != nil {
+		return errors.Wrap(err, "failed to query the edge database")
+	}
+
+	counts := map[string]int{}
+	for _, meta := range *metas {
+		namespace := keyNamespace(meta.Key)
+		if namespace == "" || (name != "" && namespace != name) {
+			continue
+		}
+		counts[namespace]++
+	}
+
+	names := make([]string, 0, len(counts))
+	for namespace := range counts {
+		names = append(names, namespace)
+	}
+	sort.Strings(names)
+
+	if opts.Quiet {
+		for _, namespace := range names {
+			fmt.Fprintln(out, namespace)
+		}
+		return nil
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(out, T("No resources found\n"))
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("NAME\tOBJECTS"))
+	for _, namespace := range names {
+		fmt.Fprintf(w, "%s\t%d\n", namespace, counts[namespace])
+	}
+	return w.Flush()
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
+)
+
+// checkReadable translates an unreadable path into a message that tells
+// the operator exactly what to do, instead of the ORM stack trace they
+// would otherwise hit much deeper in the command
+func checkReadable(path string) error {
+	file, err := os.Open(path)
+	if err == nil {
+		file.Close()
+		return nil
+	}
+	if !os.IsPermission(err) {
+		return err
+	}
+	return describePermissionError(path)
+}
+
+// describePermissionError builds the actionable permission message for path
+func describePermissionError(path string) error {
+	detail := ""
+	if info, statErr := os.Stat(path); statErr == nil {
+		detail = fmt.Sprintf(" (mode %04o)", info.Mode().Perm())
+	}
+	who := "the current user"
+	if current, userErr := user.Current(); userErr == nil {
+		who = fmt.Sprintf("user %s (uid %s)", current.Username, current.Uid)
+	}
+	return errors.Errorf(
+		"cannot read %s%s as %s: permission denied; re-run with sudo or make the file readable to a group %s belongs to",
+		path, detail, who, who)
+}
+
+// permissionCheck is one path the preflight verifies
+type permissionCheck struct {
+	path string
+	what string
+}
+
+// RunCheckPermissions verifies every path the debug subcommands may need
+// and reports each result, so a first-time user sees all missing
+// permissions at once instead of one failure per run
+func RunCheckPermissions(out io.Writer, configPath, dataSource string) error {
+	checks := []permissionCheck{
+		{path: configPath, what: "edgecore configuration"},
+	}
+	if config, err := LoadEdgecoreConfig(configPath); err == nil && dataSource == "" {
+		dataSource = config.DataBase.DataSource
+	}
+	if dataSource != "" {
+		checks = append(checks, permissionCheck{path: dataSource, what: "edge database"})
+	}
+	if _, err := os.Stat(debugserver.DefaultSocket); err == nil {
+		checks = append(checks, permissionCheck{path: debugserver.DefaultSocket, what: "edgecore debug socket"})
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("PATH\tUSED FOR\tRESULT"))
+	failed := 0
+	for _, check := range checks {
+		result := "ok"
+		if err := checkReadable(check.path); err != nil {
+			result = err.Error()
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", check.path, check.what, result)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf(T("%d of %d permission checks failed"), failed, len(checks))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// +build !windows
+
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"os/exec"
+
+	"github.com/kubeedge/kubeedge/keadm/cmd/keadm/app/cmd/util"
+)
+
+// platform specific defaults of the debug subcommands
+const (
+	// defaultEdgecoreConfigPath is where the edgecore configuration lives
+	defaultEdgecoreConfigPath = util.KubeEdgeEdgeCoreNewYaml
+	// defaultCRIEndpoint is the container runtime endpoint probed by the
+	// runtime related diagnostics
+	defaultCRIEndpoint = "unix:///var/run/dockershim.sock"
+	// stopEdgecoreHint tells the operator how to stop edgecore here
+	stopEdgecoreHint = "systemctl stop edgecore"
+)
+
+// edgecoreServiceRunning reports whether an edgecore process is running
+func edgecoreServiceRunning() bool {
+	return exec.Command("pidof", "edgecore").Run() == nil
+}
@@ -0,0 +1,51 @@
+// +build windows
+
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+// The database backed debug subcommands additionally need a keadm built
+// with CGO_ENABLED=1 and a Windows C toolchain: the sqlite driver is cgo
+// only and no CGO-free replacement is compatible with the Go version this
+// tree pins. A CGO_ENABLED=0 cross build still runs the subcommands that
+// do not touch the database; the rest fail with a clear message from
+// InitDB instead of a stack trace.
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// platform specific defaults of the debug subcommands
+const (
+	// defaultEdgecoreConfigPath is where the edgecore configuration lives
+	defaultEdgecoreConfigPath = `C:\kubeedge\config\edgecore.yaml`
+	// defaultCRIEndpoint is the container runtime endpoint probed by the
+	// runtime related diagnostics, CRI uses named pipes on Windows
+	defaultCRIEndpoint = `npipe:////./pipe/dockershim`
+	// stopEdgecoreHint tells the operator how to stop edgecore here
+	stopEdgecoreHint = "sc stop edgecore"
+)
+
+// edgecoreServiceRunning reports whether the edgecore service is running
+func edgecoreServiceRunning() bool {
+	output, err := exec.Command("sc", "query", "edgecore").Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(output), "RUNNING")
+}
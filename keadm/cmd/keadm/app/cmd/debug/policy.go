@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/policy"
+)
+
+var policyTestLongDescription = `
+    Dry-run the local admission policy of this node against a pod
+    manifest, reporting which rules it breaks, before the pod is ever
+    deployed. Edged evaluates the same rules before running a pod.
+`
+
+// PolicyTestOptions has the policy test subcommand information filled by CLI
+type PolicyTestOptions struct {
+	File   string
+	Policy string
+}
+
+// NewPolicy returns the cobra command grouping the admission policy tools
+func NewPolicy(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Work with the local admission policy of the edge node",
+	}
+	cmd.AddCommand(NewPolicyTest(out))
+	return cmd
+}
+
+// NewPolicyTest returns the cobra command dry-running the policy
+func NewPolicyTest(out io.Writer) *cobra.Command {
+	opts := &PolicyTestOptions{Policy: policy.DefaultPolicyPath}
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Dry-run the admission policy against a pod manifest",
+		Long:  policyTestLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunPolicyTest(out, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.File, "file", "f", opts.File,
+		"Pod manifest to test, - reads from stdin")
+	cmd.Flags().StringVar(&opts.Policy, "policy", opts.Policy,
+		"Path of the policy file")
+	return cmd
+}
+
+// RunPolicyTest evaluates the policy rules against the manifest
+func RunPolicyTest(out io.Writer, opts *PolicyTestOptions) error {
+	if opts.File == "" {
+		return errors.New("a pod manifest is required, use -f")
+	}
+	var data []byte
+	var err error
+	if opts.File == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(opts.File)
+	}
+	if err != nil {
+		return err
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the manifest")
+	}
+	pod := v1.Pod{}
+	if err := json.Unmarshal(jsonData, &pod); err != nil {
+		return errors.Wrap(err, "the manifest is not a pod")
+	}
+
+	rules, err := policy.Load(opts.Policy)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Fprintf(out, T("No policy exists at %s, everything is admitted\n"), opts.Policy)
+			return nil
+		}
+		return err
+	}
+
+	violations := rules.Admit(&pod)
+	broken := make(map[string]string, len(violations))
+	for _, violation := range violations {
+		broken[violation.Rule] = violation.Reason
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("RULE\tRESULT\tREASON"))
+	for _, rule := range rules.Rules {
+		if reason, ok := broken[rule.Name]; ok {
+			fmt.Fprintf(w, "%s\tDENY\t%s\n", rule.Name, reason)
+		} else {
+			fmt.Fprintf(w, "%s\tok\t\n", rule.Name)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		return errors.Errorf("the pod breaks %d of %d rules and would be rejected by edged", len(violations), len(rules.Rules))
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1"
+)
+
+var (
+	pprofLongDescription = `
+    Fetch a runtime profile from the locally running edgecore through its
+    localhost-only pprof endpoint and write it to disk for later analysis
+    with "go tool pprof".
+
+    The endpoint is disabled by default; set "enableProfiling: true" in the
+    edgecore configuration to activate it.
+`
+	pprofExample = `
+    # Collect a CPU profile (30s by default)
+    keadm debug pprof cpu
+
+    # Collect a heap profile into a specific directory
+    keadm debug pprof heap --output-dir /tmp/profiles
+
+    # Dump all goroutine stacks
+    keadm debug pprof goroutine
+`
+)
+
+// profilePaths maps the profile names accepted on the command line to
+// the paths served by net/http/pprof
+var profilePaths = map[string]string{
+	"cpu":       "/debug/pprof/profile",
+	"heap":      "/debug/pprof/heap",
+	"goroutine": "/debug/pprof/goroutine",
+}
+
+// PprofOptions has the pprof subcommand information filled by CLI
+type PprofOptions struct {
+	Port      int32
+	Seconds   int32
+	OutputDir string
+}
+
+// NewPprofOptions returns pprof options with defaults matching the edgecore configuration
+func NewPprofOptions() *PprofOptions {
+	return &PprofOptions{
+		Port:      v1alpha1.DefaultProfilingPort,
+		Seconds:   30,
+		OutputDir: ".",
+	}
+}
+
+// NewPprof returns the cobra command collecting profiles from edgecore
+func NewPprof(out io.Writer) *cobra.Command {
+	opts := NewPprofOptions()
+	cmd := &cobra.Command{
+		Use:     "pprof [cpu|heap|goroutine]",
+		Short:   "Collect a runtime profile from the running edgecore",
+		Long:    pprofLongDescription,
+		Example: pprofExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunPprof(out, opts, args[0])
+		},
+	}
+	cmd.Flags().Int32Var(&opts.Port, "port", opts.Port,
+		"Port of the edgecore pprof server on localhost")
+	cmd.Flags().Int32Var(&opts.Seconds, "seconds", opts.Seconds,
+		"Duration of the CPU profile in seconds")
+	cmd.Flags().StringVar(&opts.OutputDir, "output-dir", opts.OutputDir,
+		"Directory the profile file is written to")
+	return cmd
+}
+
+// RunPprof fetches the requested profile and writes it into opts.OutputDir
+func RunPprof(out io.Writer, opts *PprofOptions, profile string) error {
+	path, ok := profilePaths[profile]
+	if !ok {
+		return errors.Errorf("unknown profile %q, supported profiles are: cpu, heap, goroutine", profile)
+	}
+
+	url := fmt.Sprintf("http://127.0.0.1:%d%s", opts.Port, path)
+	if profile == "cpu" {
+		url = fmt.Sprintf("%s?seconds=%d", url, opts.Seconds)
+		fmt.Fprintf(out, T("Collecting CPU profile for %ds ...\n"), opts.Seconds)
+	}
+
+	client := &http.Client{Timeout: time.Duration(opts.Seconds+30) * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return errors.Wrap(err,
+			"failed to connect to the edgecore pprof server, make sure edgecore is running with enableProfiling: true")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("pprof server returned %s: %s", resp.Status, string(body))
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, os.ModePerm); err != nil {
+		return err
+	}
+	fileName := filepath.Join(opts.OutputDir,
+		fmt.Sprintf("edgecore-%s-%s.pprof", profile, time.Now().Format("20060102-150405")))
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		return errors.Wrap(err, "failed to write profile")
+	}
+	fmt.Fprintf(out, T("Profile written to %s\n"), fileName)
+	return nil
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var prefetchImagesLongDescription = `
+    Scan every pod spec cached in the edge database, compare the
+    referenced images with what the container runtime already holds and
+    pull the missing ones. After re-imaging a node this warms the runtime
+    before the pods are even scheduled, shrinking recovery time.
+
+    --mirror rewrites the registry of the pulls to a local mirror and
+    re-tags the result under the original reference; --docker-config
+    points at a directory with a config.json holding registry credentials.
+`
+
+var prefetchImagesExample = `
+    # Pull everything the cached pods reference and the runtime misses
+    keadm prefetch images
+
+    # Pull through a site-local mirror with credentials
+    keadm prefetch images --mirror registry.site.local:5000 --docker-config /etc/kubeedge/docker
+`
+
+// PrefetchImagesOptions has the prefetch images subcommand information filled by CLI
+type PrefetchImagesOptions struct {
+	Mirror         string
+	DockerConfig   string
+	DryRun         bool
+	EdgecoreConfig string
+	Input          string
+}
+
+// NewPrefetch returns the cobra command grouping the prefetch subcommands
+func NewPrefetch(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prefetch",
+		Short: "Warm the edge node from its cached state",
+	}
+	cmd.AddCommand(NewPrefetchImages(out))
+	return cmd
+}
+
+// NewPrefetchImages returns the cobra command pre-pulling missing images
+func NewPrefetchImages(out io.Writer) *cobra.Command {
+	opts := &PrefetchImagesOptions{EdgecoreConfig: DefaultEdgecoreConfigPath}
+	cmd := &cobra.Command{
+		Use:     "images",
+		Short:   "Pull the images of the cached pod specs the runtime misses",
+		Long:    prefetchImagesLongDescription,
+		Example: prefetchImagesExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunPrefetchImages(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Mirror, "mirror", opts.Mirror,
+		"Registry mirror to pull through, the result is re-tagged under the original reference")
+	cmd.Flags().StringVar(&opts.DockerConfig, "docker-config", opts.DockerConfig,
+		"Directory with a config.json holding registry credentials")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", opts.DryRun,
+		"Only report what would be pulled")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	return cmd
+}
+
+// RunPrefetchImages reconciles the referenced images with the runtime
+func RunPrefetchImages(out io.Writer, opts *PrefetchImagesOptions) error {
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	referenced, err := referencedImages()
+	if err != nil {
+		return err
+	}
+	if len(referenced) == 0 {
+		fmt.Fprintf(out, T("No pod specs are cached, nothing to prefetch\n"))
+		return nil
+	}
+	present, err := runtimeImages()
+	if err != nil {
+		// a dry run is still useful on a machine without the runtime,
+		// e.g. against a copied snapshot
+		if !opts.DryRun {
+			return err
+		}
+		fmt.Fprintf(out, T("warning: %v, treating every image as missing\n"), err)
+		present = map[string]bool{}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("IMAGE\tRESULT"))
+	failed := 0
+	for _, image := range referenced {
+		switch {
+		case present[image]:
+			fmt.Fprintf(w, "%s\t%s\n", image, "present")
+		case opts.DryRun:
+			fmt.Fprintf(w, "%s\t%s\n", image, "would pull")
+		default:
+			if err := pullImage(image, opts); err != nil {
+				fmt.Fprintf(w, "%s\tFAILED: %v\n", image, err)
+				failed++
+			} else {
+				fmt.Fprintf(w, "%s\t%s\n", image, "pulled")
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("%d images failed to pull", failed)
+	}
+	return nil
+}
+
+// referencedImages collects the images of every cached pod spec, sorted
+// and deduplicated
+func referencedImages() ([]string, error) {
+	metas, err := dao.QueryAllMeta("type", "pod")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query the edge database")
+	}
+	set := map[string]bool{}
+	for i := range *metas {
+		pod, err := MetaToPod(&(*metas)[i])
+		if err != nil {
+			continue
+		}
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			if container.Image != "" {
+				set[container.Image] = true
+			}
+		}
+	}
+	images := make([]string, 0, len(set))
+	for image := range set {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// runtimeImages lists the image references the runtime already holds
+func runtimeImages() (map[string]bool, error) {
+	output, err := exec.Command("docker", "images", "--format", "{{.Repository}}:{{.Tag}}").Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the images of the container runtime")
+	}
+	present := map[string]bool{}
+	for _, image := range strings.Fields(string(output)) {
+		present[image] = true
+		// a bare reference like nginx is stored as nginx:latest
+		present[strings.TrimSuffix(image, ":latest")] = true
+	}
+	return present, nil
+}
+
+// pullImage pulls one image, through the mirror when one is configured
+func pullImage(image string, opts *PrefetchImagesOptions) error {
+	reference := image
+	if opts.Mirror != "" {
+		reference = mirrorReference(image, opts.Mirror)
+	}
+	if output, err := exec.Command("docker", dockerArgs(opts, "pull", reference)...).CombinedOutput(); err != nil {
+		return errors.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	if reference == image {
+		return nil
+	}
+	// the pods reference the original name, give the mirrored pull that tag
+	if output, err := exec.Command("docker", dockerArgs(opts, "tag", reference, image)...).CombinedOutput(); err != nil {
+		return errors.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// dockerArgs prepends the credential configuration when one is given
+func dockerArgs(opts *PrefetchImagesOptions, args ...string) []string {
+	if opts.DockerConfig == "" {
+		return args
+	}
+	return append([]string{"--config", opts.DockerConfig}, args...)
+}
+
+// mirrorReference swaps the registry of an image reference for the mirror
+func mirrorReference(image, mirror string) string {
+	registry := imageRegistryOf(image)
+	if registry == "" {
+		return mirror + "/" + image
+	}
+	return mirror + strings.TrimPrefix(image, registry)
+}
+
+// imageRegistryOf returns the registry segment of a reference, "" when
+// the reference has none and implies docker.io
+func imageRegistryOf(image string) string {
+	first := strings.SplitN(image, "/", 2)[0]
+	if first != image && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first
+	}
+	return ""
+}
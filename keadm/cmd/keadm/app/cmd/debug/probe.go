@@ -0,0 +1,247 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"text/tabwriter"
+	"time"
+
+	quic "github.com/lucas-clemente/quic-go"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var probeTransportLongDescription = `
+    Dial the cloudcore websocket and QUIC endpoints repeatedly and compare
+    them: connect latency, handshake success rate and a loss estimate from
+    the failed attempts. Sites behind lossy or TCP-hostile links often do
+    much better on one transport than the other, and this measures which,
+    without reconfiguring edgecore back and forth.
+`
+
+var probeTransportExample = `
+    # Probe both configured transports with the defaults
+    keadm debug probe transport
+
+    # A longer measurement against explicit endpoints
+    keadm debug probe transport --websocket-server 10.1.2.3:10000 --quic-server 10.1.2.3:10001 --count 20
+`
+
+// ProbeTransportOptions has the probe transport subcommand information filled by CLI
+type ProbeTransportOptions struct {
+	EdgecoreConfig  string
+	WebSocketServer string
+	QuicServer      string
+	Count           int
+	Timeout         time.Duration
+}
+
+// NewProbe returns the cobra command grouping the connectivity probes
+func NewProbe(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "probe",
+		Short: "Measure the connectivity options this node has to cloudcore",
+	}
+	cmd.AddCommand(NewProbeTransport(out))
+	return cmd
+}
+
+// NewProbeTransport returns the cobra command comparing the hub transports
+func NewProbeTransport(out io.Writer) *cobra.Command {
+	opts := &ProbeTransportOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+		Count:          5,
+		Timeout:        5 * time.Second,
+	}
+	cmd := &cobra.Command{
+		Use:     "transport",
+		Short:   "Compare the websocket and QUIC paths to cloudcore",
+		Long:    probeTransportLongDescription,
+		Example: probeTransportExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunProbeTransport(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration holding the endpoints")
+	cmd.Flags().StringVar(&opts.WebSocketServer, "websocket-server", opts.WebSocketServer,
+		"host:port of the websocket endpoint, overriding the configured one")
+	cmd.Flags().StringVar(&opts.QuicServer, "quic-server", opts.QuicServer,
+		"host:port of the QUIC endpoint, overriding the configured one")
+	cmd.Flags().IntVar(&opts.Count, "count", opts.Count,
+		"Handshake attempts per transport")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", opts.Timeout,
+		"How long to wait for each attempt")
+	return cmd
+}
+
+// probeResult aggregates the attempts against one transport
+type probeResult struct {
+	name      string
+	server    string
+	attempts  int
+	successes int
+	min, max  time.Duration
+	total     time.Duration
+	lastError error
+}
+
+// RunProbeTransport probes both transports and prints the comparison
+func RunProbeTransport(out io.Writer, opts *ProbeTransportOptions) error {
+	websocketServer, quicServer := opts.WebSocketServer, opts.QuicServer
+	if websocketServer == "" || quicServer == "" {
+		config, err := LoadEdgecoreConfig(opts.EdgecoreConfig)
+		if err == nil {
+			if websocketServer == "" && config.Modules.EdgeHub.WebSocket != nil {
+				websocketServer = config.Modules.EdgeHub.WebSocket.Server
+			}
+			if quicServer == "" && config.Modules.EdgeHub.Quic != nil {
+				quicServer = config.Modules.EdgeHub.Quic.Server
+			}
+		} else if opts.WebSocketServer == "" && opts.QuicServer == "" {
+			return err
+		}
+	}
+	if websocketServer == "" && quicServer == "" {
+		return errors.New("no transport endpoints configured, give them with --websocket-server and --quic-server")
+	}
+	if opts.Count < 1 {
+		return errors.New("--count must be at least 1")
+	}
+
+	var results []*probeResult
+	if websocketServer != "" {
+		results = append(results, runProbe("websocket", websocketServer, opts, dialWebSocketTransport))
+	}
+	if quicServer != "" {
+		results = append(results, runProbe("quic", quicServer, opts, dialQuicTransport))
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("TRANSPORT\tSERVER\tHANDSHAKES\tLOSS\tMIN\tAVG\tMAX"))
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%d/%d\t%.0f%%\t%s\t%s\t%s\n",
+			result.name, result.server, result.successes, result.attempts,
+			100*float64(result.attempts-result.successes)/float64(result.attempts),
+			probeLatency(result.min, result.successes),
+			probeLatency(result.avg(), result.successes),
+			probeLatency(result.max, result.successes))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if result.successes == 0 && result.lastError != nil {
+			fmt.Fprintf(out, T("%s failed: %v\n"), result.name, result.lastError)
+		}
+	}
+	fmt.Fprintf(out, T("Recommendation: %s\n"), recommendTransport(results))
+	return nil
+}
+
+// runProbe performs the attempts against one transport
+func runProbe(name, server string, opts *ProbeTransportOptions, dial func(server string, timeout time.Duration) error) *probeResult {
+	result := &probeResult{name: name, server: server, attempts: opts.Count}
+	for i := 0; i < opts.Count; i++ {
+		if i > 0 {
+			// pace the attempts so a loss estimate means more than one
+			// burst hitting the same congestion event
+			time.Sleep(200 * time.Millisecond)
+		}
+		before := time.Now()
+		if err := dial(server, opts.Timeout); err != nil {
+			result.lastError = err
+			continue
+		}
+		elapsed := time.Since(before)
+		result.successes++
+		result.total += elapsed
+		if result.min == 0 || elapsed < result.min {
+			result.min = elapsed
+		}
+		if elapsed > result.max {
+			result.max = elapsed
+		}
+	}
+	return result
+}
+
+// dialWebSocketTransport performs the TCP and TLS handshakes the
+// websocket client starts with; certificate verification is check tls's
+// job, the probe measures reachability and latency
+func dialWebSocketTransport(server string, timeout time.Duration) error {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", server, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// dialQuicTransport performs the QUIC handshake over UDP
+func dialQuicTransport(server string, timeout time.Duration) error {
+	session, err := quic.DialAddr(server, &tls.Config{InsecureSkipVerify: true},
+		&quic.Config{HandshakeTimeout: timeout})
+	if err != nil {
+		return err
+	}
+	return session.Close()
+}
+
+// avg is the mean latency of the successful attempts
+func (r *probeResult) avg() time.Duration {
+	if r.successes == 0 {
+		return 0
+	}
+	return r.total / time.Duration(r.successes)
+}
+
+// probeLatency renders a latency cell, "-" when nothing succeeded
+func probeLatency(d time.Duration, successes int) string {
+	if successes == 0 {
+		return "-"
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+// recommendTransport picks the transport with the better success rate,
+// breaking ties on average latency
+func recommendTransport(results []*probeResult) string {
+	var best *probeResult
+	for _, result := range results {
+		if result.successes == 0 {
+			continue
+		}
+		if best == nil ||
+			result.successes > best.successes ||
+			(result.successes == best.successes && result.avg() < best.avg()) {
+			best = result
+		}
+	}
+	switch {
+	case best == nil:
+		return "neither transport completed a handshake, check connectivity first"
+	case len(results) == 1:
+		return fmt.Sprintf("only %s was probed", best.name)
+	default:
+		return fmt.Sprintf("use %s at this site", best.name)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var reportLongDescription = `
+    Assemble a diagnostic bundle of this node: a summary of the cached
+    objects and heartbeat, the edgecore configuration and the tail of the
+    local audit log, as a tar.gz. With --upload the bundle additionally
+    travels over the existing cloudhub connection to the cloud-side
+    collector, so sites reachable only through KubeEdge can still file
+    diagnostics centrally.
+`
+
+// reportUploadLimit caps the bundle size sent through the tunnel, the
+// hub connection is not a file transfer channel
+const reportUploadLimit = 512 * 1024
+
+// ReportOptions has the report subcommand information filled by CLI
+type ReportOptions struct {
+	Output         string
+	Upload         bool
+	Socket         string
+	Timeout        int64
+	EdgecoreConfig string
+	Input          string
+}
+
+// NewReport returns the cobra command assembling diagnostic bundles
+func NewReport(out io.Writer) *cobra.Command {
+	opts := &ReportOptions{
+		Socket:         debugserver.DefaultSocket,
+		Timeout:        30,
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Assemble a diagnostic bundle, optionally uploading it through the cloud tunnel",
+		Long:  reportLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunReport(out, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", opts.Output,
+		"Path of the bundle, defaults to keadm-report-<timestamp>.tar.gz in the working directory")
+	cmd.Flags().BoolVar(&opts.Upload, "upload", opts.Upload,
+		"Send the bundle over the cloudhub connection to the cloud-side collector")
+	cmd.Flags().StringVar(&opts.Socket, "socket", opts.Socket,
+		"Path of the edgecore debug socket used for the upload")
+	cmd.Flags().Int64Var(&opts.Timeout, "timeout", opts.Timeout,
+		"Seconds to wait for the upload handoff")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	return cmd
+}
+
+// RunReport builds the bundle and optionally hands it to edgehub
+func RunReport(out io.Writer, opts *ReportOptions) error {
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("keadm-report-%s.tar.gz", time.Now().UTC().Format("20060102-150405"))
+	bundle, err := buildReportBundle(opts)
+	if err != nil {
+		return err
+	}
+	output := opts.Output
+	if output == "" {
+		output = name
+	}
+	if err := ioutil.WriteFile(output, bundle, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, T("Wrote %s (%d bytes)\n"), output, len(bundle))
+
+	if !opts.Upload {
+		return nil
+	}
+	if len(bundle) > reportUploadLimit {
+		return errors.Errorf("the bundle is %d bytes, larger than the %d byte upload limit of the tunnel; ship it out of band",
+			len(bundle), reportUploadLimit)
+	}
+	body, err := json.Marshal(map[string]string{
+		"filename": name,
+		"data":     base64.StdEncoding.EncodeToString(bundle),
+	})
+	if err != nil {
+		return err
+	}
+	message := model.NewMessage("").
+		BuildRouter("keadm", "", "node/report/"+name, model.InsertOperation).
+		FillBody(string(body))
+	response, err := sendDebugRequest(opts.Socket, &debugserver.Request{
+		Op:      debugserver.OpInject,
+		Module:  edgehub.ModuleNameEdgeHub,
+		Message: message,
+	})
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.Errorf("edgehub did not accept the bundle: %s", response.Error)
+	}
+	fmt.Fprintf(out, T("Uploaded %s, cloudcore stores it under /var/lib/kubeedge/reports\n"), name)
+	return nil
+}
+
+// buildReportBundle assembles the tar.gz in memory
+func buildReportBundle(opts *ReportOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	zipper := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(zipper)
+
+	summary, err := buildReportSummary()
+	if err != nil {
+		return nil, err
+	}
+	if err := addReportFile(archive, "summary.json", summary); err != nil {
+		return nil, err
+	}
+	// best effort extras, a report from a broken node is still a report
+	if config, err := ioutil.ReadFile(opts.EdgecoreConfig); err == nil {
+		if err := addReportFile(archive, "edgecore.yaml", config); err != nil {
+			return nil, err
+		}
+	}
+	if audit, err := ioutil.ReadFile(auditLogPath); err == nil {
+		if err := addReportFile(archive, "keadm-audit.log", audit); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	if err := zipper.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// buildReportSummary captures the state of the cached objects as JSON
+func buildReportSummary() ([]byte, error) {
+	hostname, _ := os.Hostname()
+	summary := map[string]interface{}{
+		"keadmOutputVersion": KeadmOutputVersion,
+		"generatedAt":        time.Now().UTC().Format(time.RFC3339),
+		"hostname":           hostname,
+	}
+	if metas, err := dao.QueryMetaKeyTypes(); err == nil {
+		counts := map[string]int{}
+		for _, meta := range *metas {
+			counts[meta.Type]++
+		}
+		summary["objectCounts"] = counts
+	}
+	if renewed, err := latestLeaseRenewal(); err == nil && renewed != "" {
+		summary["heartbeat"] = renewed
+	}
+	return json.MarshalIndent(summary, "", "  ")
+}
+
+// addReportFile writes one file into the bundle
+func addReportFile(archive *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := archive.Write(data)
+	return err
+}
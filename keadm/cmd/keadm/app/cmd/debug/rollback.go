@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var (
+	rollbackLongDescription = `
+    Restore the previous value of a resource in the edge database. Whenever
+    a debug subcommand modifies a row it stores the value it replaces in a
+    backup table; rollback swaps that saved value back in, and running it a
+    second time undoes the rollback again.
+`
+	rollbackExample = `
+    # List resources with a saved backup
+    keadm debug rollback --list
+
+    # Restore a pod to its value before the last local modification
+    keadm debug rollback default/pod/nginx
+`
+)
+
+// RollbackOptions has the rollback subcommand information filled by CLI
+type RollbackOptions struct {
+	EdgecoreConfig string
+	Input          string
+	List           bool
+}
+
+// NewRollbackOptions returns rollback options with defaults
+func NewRollbackOptions() *RollbackOptions {
+	return &RollbackOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+}
+
+// NewRollback returns the cobra command restoring backed up resources
+func NewRollback(out io.Writer) *cobra.Command {
+	opts := NewRollbackOptions()
+	cmd := &cobra.Command{
+		Use:     "rollback <namespace/type/name>",
+		Short:   "Restore a resource in the edge database from its local backup",
+		Long:    rollbackLongDescription,
+		Example: rollbackExample,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.List {
+				return RunRollbackList(out, opts)
+			}
+			if len(args) != 1 {
+				return errors.New("a resource key like default/pod/nginx is required, or use --list")
+			}
+			return RunRollback(out, opts, args[0])
+		},
+	}
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	cmd.Flags().BoolVar(&opts.List, "list", opts.List,
+		"List resources that have a saved backup instead of restoring one")
+	return cmd
+}
+
+// RunRollback restores the saved backup of the given resource key
+func RunRollback(out io.Writer, opts *RollbackOptions, key string) error {
+	if err := requireOperator("debug rollback"); err != nil {
+		return err
+	}
+	if len(strings.Split(key, "/")) != 3 {
+		return errors.Errorf("invalid resource key %q, expected namespace/type/name", key)
+	}
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	if err := dao.RestoreMetaByKey(key); err != nil {
+		if err == dao.ErrNoBackup {
+			return errors.Errorf("no backup exists for %s, nothing to roll back", key)
+		}
+		return errors.Wrapf(err, "failed to roll back %s", key)
+	}
+	fmt.Fprintf(out, T("Rolled back %s to its previous value\n"), key)
+	return nil
+}
+
+// RunRollbackList prints the resources that currently have a saved backup
+func RunRollbackList(out io.Writer, opts *RollbackOptions) error {
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	backups, err := dao.QueryAllBackups()
+	if err != nil {
+		return errors.Wrap(err, "failed to query the backup table")
+	}
+	if len(*backups) == 0 {
+		fmt.Fprintln(out, T("No backups found"))
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("KEY\tTYPE\tBACKUP-TIME"))
+	for _, backup := range *backups {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", backup.Key, backup.Type, backup.BackupTime)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var (
+	simulateOfflineLongDescription = `
+    Cleanly sever the connection between the running edgecore and the
+    cloud for the given duration, without touching firewalls, and restore
+    it afterwards. While offline the node runs on its cached state; once
+    the duration elapses the command reports which pods kept running and
+    which resources changed locally and queued for sync. Run this before
+    production to verify the node survives a real disconnection.
+
+    The debug socket is disabled by default; set "debugServerSocket" in
+    the edgecore configuration to enable it.
+`
+	simulateOfflineExample = `
+    # Take the node offline for ten minutes and report the outcome
+    keadm debug simulate offline --duration 10m
+
+    # Sever the connection and return immediately without the report
+    keadm debug simulate offline --duration 1h --wait=false
+`
+)
+
+// SimulateOfflineOptions has the simulate offline subcommand information filled by CLI
+type SimulateOfflineOptions struct {
+	Duration       time.Duration
+	Wait           bool
+	Socket         string
+	Timeout        int64
+	EdgecoreConfig string
+}
+
+// NewSimulateOfflineOptions returns simulate offline options with defaults
+func NewSimulateOfflineOptions() *SimulateOfflineOptions {
+	return &SimulateOfflineOptions{
+		Duration:       10 * time.Minute,
+		Wait:           true,
+		Socket:         debugserver.DefaultSocket,
+		Timeout:        30,
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+}
+
+// NewSimulate returns the cobra command grouping resilience simulations
+func NewSimulate(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate failure conditions for resilience testing",
+	}
+	cmd.AddCommand(NewSimulateOffline(out))
+	return cmd
+}
+
+// NewSimulateOffline returns the cobra command severing the cloud connection
+func NewSimulateOffline(out io.Writer) *cobra.Command {
+	opts := NewSimulateOfflineOptions()
+	cmd := &cobra.Command{
+		Use:     "offline",
+		Short:   "Sever the cloud connection for a duration and report the outcome",
+		Long:    simulateOfflineLongDescription,
+		Example: simulateOfflineExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunSimulateOffline(out, opts)
+		},
+	}
+	cmd.Flags().DurationVar(&opts.Duration, "duration", opts.Duration,
+		"How long the node stays offline")
+	cmd.Flags().BoolVar(&opts.Wait, "wait", opts.Wait,
+		"Wait for the duration to elapse and report the outcome")
+	cmd.Flags().StringVar(&opts.Socket, "socket", opts.Socket,
+		"Path of the edgecore debug socket")
+	cmd.Flags().Int64Var(&opts.Timeout, "timeout", opts.Timeout,
+		"Seconds to wait for the edgehub response")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	return cmd
+}
+
+// RunSimulateOffline severs the cloud connection and, after the duration,
+// reports pod survival and what queued for sync
+func RunSimulateOffline(out io.Writer, opts *SimulateOfflineOptions) error {
+	if err := requireOperator("debug simulate offline"); err != nil {
+		return err
+	}
+	if opts.Duration <= 0 {
+		return errors.New("--duration must be positive")
+	}
+	if err := InitDB(opts.EdgecoreConfig, ""); err != nil {
+		return err
+	}
+	before, err := snapshotReadyPods()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+
+	message := model.NewMessage("").
+		BuildRouter("keadm", "", "node", edgehub.OperationSimulateOffline).
+		FillBody(opts.Duration.String())
+	response, err := sendDebugRequest(opts.Socket, &debugserver.Request{
+		Op:             debugserver.OpInject,
+		Module:         edgehub.ModuleNameEdgeHub,
+		Sync:           true,
+		TimeoutSeconds: opts.Timeout,
+		Message:        message,
+	})
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.Errorf("edgehub failed to go offline: %s", response.Error)
+	}
+	fmt.Fprintf(out, T("Severed the cloud connection for %v\n"), opts.Duration)
+	if !opts.Wait {
+		return nil
+	}
+
+	// a couple of extra seconds cover the reconnect backoff of edgehub
+	time.Sleep(opts.Duration + 5*time.Second)
+
+	after, err := snapshotReadyPods()
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("POD\tBEFORE\tAFTER\tRESULT"))
+	kept := 0
+	for _, key := range sortedKeys(before) {
+		result := "STOPPED"
+		if after[key] {
+			result = "kept running"
+			kept++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", key, readiness(before[key]), readiness(after[key]), result)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, T("%d of %d pods kept running through the offline period\n"), kept, len(before))
+
+	queued, err := dao.QueryAllMeta("updated_at__gte", start.UTC().Format(time.RFC3339))
+	if err != nil {
+		return errors.Wrap(err, "failed to query the edge database")
+	}
+	fmt.Fprintf(out, T("%d resources changed locally and queued for sync\n"), len(*queued))
+	for _, meta := range *queued {
+		fmt.Fprintf(out, "  %s\n", meta.Key)
+	}
+	return nil
+}
+
+// snapshotReadyPods records the readiness of every cached pod by key
+func snapshotReadyPods() (map[string]bool, error) {
+	metas, err := dao.QueryMetaPage("pod", "", 0, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query the edge database")
+	}
+	ready := make(map[string]bool, len(*metas))
+	for i := range *metas {
+		pod, err := MetaToPod(&(*metas)[i])
+		if err != nil {
+			continue
+		}
+		ready[(*metas)[i].Key] = podReady(pod)
+	}
+	return ready, nil
+}
+
+// sortedKeys returns the keys of a readiness snapshot in stable order
+func sortedKeys(snapshot map[string]bool) []string {
+	keys := make([]string, 0, len(snapshot))
+	for key := range snapshot {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// readiness renders a pod readiness snapshot entry
+func readiness(ready bool) string {
+	if ready {
+		return "ready"
+	}
+	return "not-ready"
+}
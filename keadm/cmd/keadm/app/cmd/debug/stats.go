@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+)
+
+var statsLongDescription = `
+    Show the per-module message counters beehive persists: messages in and
+    out, the average dispatch latency and delivery errors. A module whose
+    dispatch latency grows or whose error count climbs is the one to tune,
+    e.g. by adjusting its sync interval, before guessing from logs.
+`
+
+// StatsOptions has the stats command information filled by CLI
+type StatsOptions struct {
+	StatsFile string
+}
+
+// NewStats returns the cobra command printing the message statistics
+func NewStats(out io.Writer) *cobra.Command {
+	opts := &StatsOptions{
+		StatsFile: beehiveContext.StatsFilePathDefault,
+	}
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show the per-module message statistics beehive persists",
+		Long:  statsLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunStats(out, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.StatsFile, "stats-file", opts.StatsFile,
+		"Path of the persisted statistics, e.g. from a collected node archive")
+	return cmd
+}
+
+// RunStats prints the persisted message statistics
+func RunStats(out io.Writer, opts *StatsOptions) error {
+	data, err := ioutil.ReadFile(opts.StatsFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.Errorf("no statistics at %s, check messageStatsFile in the edgecore configuration", opts.StatsFile)
+		}
+		return errors.Wrap(err, "failed to read the persisted statistics")
+	}
+	var stats beehiveContext.MessageStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return errors.Wrapf(err, "the statistics at %s are not readable", opts.StatsFile)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("MODULE\tIN\tOUT\tAVG DISPATCH\tERRORS"))
+	for _, module := range stats.Modules {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%d\n",
+			module.Module, module.MessagesIn, module.MessagesOut,
+			module.AvgDispatchLatency.Round(time.Microsecond), module.Errors)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, T("Collected at %s\n"), stats.UpdatedAt.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
+)
+
+var (
+	syncLongDescription = `
+    Ask the running edgecore to re-read resources from its database and
+    dispatch them to the module acting on them, so manual edits and
+    imports (e.g. keadm debug apply) take effect without a restart.
+
+    The debug socket is disabled by default; set "debugServerSocket" in
+    the edgecore configuration to enable it.
+`
+	syncExample = `
+    # Reconcile everything cached in the edge database
+    keadm debug sync
+
+    # Reconcile all pods of one namespace
+    keadm debug sync default/pod
+
+    # Reconcile a single resource after editing it
+    keadm debug sync default/pod/nginx
+`
+)
+
+// SyncOptions has the sync subcommand information filled by CLI
+type SyncOptions struct {
+	Socket string
+	Module string
+}
+
+// NewSyncOptions returns sync options with defaults
+func NewSyncOptions() *SyncOptions {
+	return &SyncOptions{
+		Socket: debugserver.DefaultSocket,
+		Module: "edged",
+	}
+}
+
+// NewSync returns the cobra command triggering a reconcile in edgecore
+func NewSync(out io.Writer) *cobra.Command {
+	opts := NewSyncOptions()
+	cmd := &cobra.Command{
+		Use:     "sync [type | namespace/type | namespace/type/name]",
+		Short:   "Make the running edgecore reconcile cached resources",
+		Long:    syncLongDescription,
+		Example: syncExample,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resource := ""
+			if len(args) == 1 {
+				resource = args[0]
+			}
+			return RunSync(out, opts, resource)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Socket, "socket", opts.Socket,
+		"Path of the edgecore debug socket")
+	cmd.Flags().StringVar(&opts.Module, "module", opts.Module,
+		"Beehive module the resources are dispatched to")
+	return cmd
+}
+
+// RunSync asks edgecore to re-dispatch the selected resources
+func RunSync(out io.Writer, opts *SyncOptions, resource string) error {
+	if err := requireOperator("debug sync"); err != nil {
+		return err
+	}
+	response, err := sendDebugRequest(opts.Socket, &debugserver.Request{
+		Op:       debugserver.OpSync,
+		Module:   opts.Module,
+		Resource: resource,
+	})
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.Errorf("edgecore failed to sync: %s", response.Error)
+	}
+	fmt.Fprintf(out, T("Synced %d resources to %s\n"), response.Synced, opts.Module)
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// tableRenderer writes the listing tables through a tabwriter while
+// honouring --columns and --max-column-width, so the output stays
+// readable on 80 column serial consoles where the full table wraps
+type tableRenderer struct {
+	w *tabwriter.Writer
+	// columns is the upper-cased --columns selection, nil keeps everything
+	columns []string
+	width   int
+	// keep is the per-column mask derived from the header row
+	keep []bool
+	err  error
+}
+
+// newTableRenderer builds a renderer from the listing options
+func newTableRenderer(out io.Writer, opts *GetOptions) *tableRenderer {
+	r := &tableRenderer{
+		w:     tabwriter.NewWriter(out, 0, 8, 3, ' ', 0),
+		width: opts.MaxColumnWidth,
+	}
+	if opts.Columns != "" {
+		for _, column := range strings.Split(opts.Columns, ",") {
+			r.columns = append(r.columns, strings.ToUpper(strings.TrimSpace(column)))
+		}
+	}
+	return r
+}
+
+// header derives the column mask from the header row and prints the kept
+// part of it. A requested column the table does not have surfaces at flush
+func (r *tableRenderer) header(line string) {
+	cells := strings.Split(line, "\t")
+	if r.columns == nil {
+		fmt.Fprintln(r.w, line)
+		return
+	}
+	r.keep = make([]bool, len(cells))
+	for _, wanted := range r.columns {
+		found := false
+		for i, cell := range cells {
+			if strings.ToUpper(cell) == wanted {
+				r.keep[i] = true
+				found = true
+			}
+		}
+		if !found && r.err == nil {
+			r.err = errors.Errorf("unknown column %s, this table has: %s", wanted, strings.Join(cells, ", "))
+		}
+	}
+	r.row(line)
+}
+
+// row prints one row, keeping the selected columns and truncating long cells
+func (r *tableRenderer) row(line string) {
+	cells := strings.Split(line, "\t")
+	var kept []string
+	for i, cell := range cells {
+		// rows with fewer cells than the header, like decode errors,
+		// keep their trailing cells
+		if r.keep != nil && i < len(r.keep) && !r.keep[i] {
+			continue
+		}
+		kept = append(kept, truncateCell(cell, r.width))
+	}
+	fmt.Fprintln(r.w, strings.Join(kept, "\t"))
+}
+
+// flush aligns and writes the buffered table
+func (r *tableRenderer) flush() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.w.Flush()
+}
+
+// truncateCell shortens a cell to the width with a trailing ellipsis,
+// counting runes so multibyte names do not truncate mid-character
+func truncateCell(cell string, width int) string {
+	if width <= 0 {
+		return cell
+	}
+	runes := []rune(cell)
+	if len(runes) <= width {
+		return cell
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	beehiveContext "github.com/kubeedge/beehive/pkg/core/context"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1"
+)
+
+var (
+	traceLongDescription = `
+    Show the most recent beehive message hops recorded by the running
+    edgecore, including which module sent or received each message and
+    the latency since the message was built.
+
+    Tracing is disabled by default; set "enableMessageTrace: true" together
+    with "enableProfiling: true" in the edgecore configuration to use it.
+`
+	traceExample = `
+    # Show all recorded message hops
+    keadm debug trace
+
+    # Show only hops through metamanager
+    keadm debug trace --module metamanager
+`
+)
+
+// TraceOptions has the trace subcommand information filled by CLI
+type TraceOptions struct {
+	Port   int32
+	Module string
+}
+
+// NewTraceOptions returns trace options with the edgecore defaults
+func NewTraceOptions() *TraceOptions {
+	return &TraceOptions{
+		Port: v1alpha1.DefaultProfilingPort,
+	}
+}
+
+// NewTrace returns the cobra command printing the beehive message trace
+func NewTrace(out io.Writer) *cobra.Command {
+	opts := NewTraceOptions()
+	cmd := &cobra.Command{
+		Use:     "trace",
+		Short:   "Show recent beehive message hops recorded by edgecore",
+		Long:    traceLongDescription,
+		Example: traceExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunTrace(out, opts)
+		},
+	}
+	cmd.Flags().Int32Var(&opts.Port, "port", opts.Port,
+		"Port of the edgecore profiling server on localhost")
+	cmd.Flags().StringVar(&opts.Module, "module", opts.Module,
+		"Only show hops sent to or from this module")
+	return cmd
+}
+
+// RunTrace fetches the recorded hops from edgecore and prints them oldest first
+func RunTrace(out io.Writer, opts *TraceOptions) error {
+	url := fmt.Sprintf("http://127.0.0.1:%d/debug/messagetrace", opts.Port)
+	resp, err := http.Get(url)
+	if err != nil {
+		return errors.Wrap(err,
+			"failed to connect to the edgecore profiling server, make sure edgecore is running with enableProfiling: true")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("profiling server returned %s", resp.Status)
+	}
+
+	var records []beehiveContext.TraceRecord
+	if err := json.NewDecoder(resp.Body).Decode(&records); err != nil {
+		return errors.Wrap(err, "failed to decode trace records")
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(out, T("No message hops recorded, make sure enableMessageTrace is set in the edgecore configuration"))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("TIME\tEVENT\tMODULE\tSOURCE\tOPERATION\tRESOURCE\tLATENCY"))
+	for _, r := range records {
+		if opts.Module != "" && r.Module != opts.Module && r.Source != opts.Module {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Timestamp.Format("15:04:05.000"), r.Event, r.Module, r.Source, r.Operation, r.Resource, r.Latency)
+	}
+	return w.Flush()
+}
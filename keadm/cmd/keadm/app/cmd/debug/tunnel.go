@@ -0,0 +1,243 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
+	"github.com/kubeedge/kubeedge/edge/pkg/edgehub"
+)
+
+var tunnelLongDescription = `
+    Reach edge debug endpoints like the debug proxy from the cloud side
+    without a VPN, over the existing cloudhub connection. The command has
+    two halves: on the edge node it opens a local port for cloud initiated
+    tunnel exchanges (edgehub only relays to ports opened this way), and
+    with --node it runs on the cloud side next to cloudcore, forwarding a
+    local port to the allowed port on that node. Both halves stay active
+    while the command runs and close again on interrupt.
+`
+
+var tunnelExample = `
+    # On the edge node: let the cloud reach the local debug proxy
+    keadm debug tunnel --local-port 10350
+
+    # On the cloud side: forward localhost:10350 to that node
+    keadm debug tunnel --node edge-node1 --local-port 10350
+`
+
+// tunnelRequestLimit caps the request bytes of one relayed exchange, the
+// hub connection is no bulk transfer channel
+const tunnelRequestLimit = 1 << 20
+
+// TunnelOptions has the tunnel subcommand information filled by CLI
+type TunnelOptions struct {
+	LocalPort   int
+	Socket      string
+	Timeout     int64
+	Node        string
+	RemotePort  int
+	CloudSocket string
+}
+
+// NewTunnel returns the cobra command opening a reverse tunnel port
+func NewTunnel(out io.Writer) *cobra.Command {
+	opts := &TunnelOptions{
+		Socket:      debugserver.DefaultSocket,
+		Timeout:     30,
+		CloudSocket: collectSocketDefault,
+	}
+	cmd := &cobra.Command{
+		Use:     "tunnel",
+		Short:   "Open a local port for cloud-to-edge tunnel access",
+		Long:    tunnelLongDescription,
+		Example: tunnelExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunTunnel(out, opts)
+		},
+	}
+	cmd.Flags().IntVar(&opts.LocalPort, "local-port", opts.LocalPort,
+		"Local port the cloud may tunnel to while this command runs")
+	cmd.Flags().StringVar(&opts.Socket, "socket", opts.Socket,
+		"Path of the edgecore debug socket")
+	cmd.Flags().Int64Var(&opts.Timeout, "timeout", opts.Timeout,
+		"Seconds to wait for edgecore to acknowledge the tunnel, and per exchange in cloud mode")
+	cmd.Flags().StringVar(&opts.Node, "node", opts.Node,
+		"Run on the cloud side and forward to this node instead of opening a port")
+	cmd.Flags().IntVar(&opts.RemotePort, "remote-port", opts.RemotePort,
+		"Port on the node the exchanges go to in cloud mode, defaults to --local-port")
+	cmd.Flags().StringVar(&opts.CloudSocket, "cloud-socket", opts.CloudSocket,
+		"Path of the cloudhub unix socket used in cloud mode")
+	return cmd
+}
+
+// RunTunnel opens the port, waits for an interrupt and closes it again
+func RunTunnel(out io.Writer, opts *TunnelOptions) error {
+	if opts.LocalPort <= 0 || opts.LocalPort > 65535 {
+		return errors.New("--local-port is required")
+	}
+	if opts.Node != "" {
+		return RunTunnelCloud(out, opts)
+	}
+	if err := requireOperator("debug tunnel"); err != nil {
+		return err
+	}
+	if err := sendTunnelControl(opts, edgehub.OperationTunnelAllow); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, T("Port %d is now reachable through the cloud tunnel, press Ctrl-C to close it\n"), opts.LocalPort)
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	<-interrupted
+
+	if err := sendTunnelControl(opts, edgehub.OperationTunnelRevoke); err != nil {
+		return errors.Wrap(err, "failed to close the tunnel port, restart edgecore to be sure")
+	}
+	fmt.Fprintf(out, T("Closed tunnel port %d\n"), opts.LocalPort)
+	return nil
+}
+
+// RunTunnelCloud listens on the local port on the cloud side and relays
+// each accepted connection as one exchange to the node, through the
+// cloudhub unix socket the way collect goes out
+func RunTunnelCloud(out io.Writer, opts *TunnelOptions) error {
+	if opts.RemotePort == 0 {
+		opts.RemotePort = opts.LocalPort
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", opts.LocalPort))
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on local port %d", opts.LocalPort)
+	}
+	fmt.Fprintf(out, T("Forwarding 127.0.0.1:%d to port %d on node %s, press Ctrl-C to stop\n"),
+		opts.LocalPort, opts.RemotePort, opts.Node)
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	go func() {
+		<-interrupted
+		listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			fmt.Fprintf(out, T("Closed tunnel port %d\n"), opts.LocalPort)
+			return nil
+		}
+		go relayTunnelConn(opts, conn)
+	}
+}
+
+// relayTunnelConn performs the cloud half of one tunnel exchange: the
+// request bytes of one local connection go to the node, the relayed
+// response comes back on the same connection. The edge half answers one
+// request per exchange, so each connection is one round trip rather than
+// a raw byte stream
+func relayTunnelConn(opts *TunnelOptions, conn net.Conn) {
+	defer conn.Close()
+	payload, err := readTunnelPayload(conn)
+	if err != nil {
+		return
+	}
+	// timeoutSeconds tells cloudhub how long to hold the exchange open,
+	// the edge side ignores the extra field
+	request, err := json.Marshal(map[string]interface{}{
+		"port":           opts.RemotePort,
+		"data":           base64.StdEncoding.EncodeToString(payload),
+		"timeoutSeconds": opts.Timeout,
+	})
+	if err != nil {
+		return
+	}
+	// the resource carries the node for the cloudhub dispatch, the debug
+	// segment routes the response back to the socket waiter
+	message := model.NewMessage("").
+		BuildRouter("keadm", "resource", "node/"+opts.Node+"/default/debug/tunnel", edgehub.OperationTunnel).
+		FillBody(string(request))
+	response, err := sendCloudHubRequest(opts.CloudSocket, time.Duration(opts.Timeout)*time.Second, message)
+	if err != nil {
+		fmt.Fprintf(conn, "tunnel error: %v", err)
+		return
+	}
+	content := fmt.Sprintf("%v", response.GetContent())
+	reply, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		// the edge answers errors as plain text
+		fmt.Fprint(conn, content)
+		return
+	}
+	conn.Write(reply)
+}
+
+// readTunnelPayload reads the request bytes of one exchange: everything
+// the client sends until it half-closes or pauses, protocols like HTTP
+// keep the connection open while waiting for the response
+func readTunnelPayload(conn net.Conn) ([]byte, error) {
+	var payload []byte
+	buffer := make([]byte, 32<<10)
+	// the first read waits for the client, later ones only bridge the
+	// gaps inside one request
+	deadline := 10 * time.Second
+	for len(payload) < tunnelRequestLimit {
+		conn.SetReadDeadline(time.Now().Add(deadline))
+		n, err := conn.Read(buffer)
+		payload = append(payload, buffer[:n]...)
+		if err != nil {
+			if len(payload) > 0 {
+				if netErr, ok := err.(net.Error); err == io.EOF || (ok && netErr.Timeout()) {
+					return payload, nil
+				}
+			}
+			return nil, err
+		}
+		deadline = 200 * time.Millisecond
+	}
+	return payload, nil
+}
+
+// sendTunnelControl injects one allow or revoke into edgehub
+func sendTunnelControl(opts *TunnelOptions, operation string) error {
+	message := model.NewMessage("").
+		BuildRouter("keadm", "", "node/tunnel", operation).
+		FillBody(fmt.Sprintf("%d", opts.LocalPort))
+	response, err := sendDebugRequest(opts.Socket, &debugserver.Request{
+		Op:             debugserver.OpInject,
+		Module:         edgehub.ModuleNameEdgeHub,
+		Sync:           true,
+		TimeoutSeconds: opts.Timeout,
+		Message:        message,
+	})
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.Errorf("edgecore rejected the tunnel control: %s", response.Error)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+	"github.com/kubeedge/kubeedge/common/constants"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/debugserver"
+	"github.com/kubeedge/kubeedge/edge/pkg/common/modules"
+	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dtcommon"
+	"github.com/kubeedge/kubeedge/edge/pkg/devicetwin/dttype"
+)
+
+var twinSetLongDescription = `
+    Send a twin update for one device property into the running edgecore,
+    exactly shaped like the update a mapper publishes over MQTT. When a
+    value set this way shows up in the twin database and the cloud, the
+    edge pipeline works and the mapper is the broken piece; when it does
+    not, the pipeline itself is.
+`
+
+var twinSetExample = `
+    # Report a value the way the mapper would
+    keadm debug twin set water-valve open=true
+
+    # Set the desired value instead, actuating through the delta topic
+    keadm debug twin set water-valve open=true --desired
+`
+
+// TwinSetOptions has the twin set subcommand information filled by CLI
+type TwinSetOptions struct {
+	Socket         string
+	Desired        bool
+	Sync           bool
+	TimeoutSeconds int64
+}
+
+// NewTwin returns the cobra command grouping the twin tools
+func NewTwin(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "twin",
+		Short: "Interact with the device twins of the running edgecore",
+	}
+	cmd.AddCommand(NewTwinSet(out))
+	return cmd
+}
+
+// NewTwinSet returns the cobra command sending a manual twin update
+func NewTwinSet(out io.Writer) *cobra.Command {
+	opts := &TwinSetOptions{
+		Socket: debugserver.DefaultSocket,
+	}
+	cmd := &cobra.Command{
+		Use:     "set <device> <property>=<value>",
+		Short:   "Send a twin update for one device property",
+		Long:    twinSetLongDescription,
+		Example: twinSetExample,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunTwinSet(out, opts, args[0], args[1])
+		},
+	}
+	cmd.Flags().StringVar(&opts.Socket, "socket", opts.Socket,
+		"Path of the edgecore debug socket")
+	cmd.Flags().BoolVar(&opts.Desired, "desired", opts.Desired,
+		"Set the desired value instead of reporting like a mapper")
+	return cmd
+}
+
+// RunTwinSet builds the mapper style update and injects it into devicetwin
+func RunTwinSet(out io.Writer, opts *TwinSetOptions, device, assignment string) error {
+	if err := requireOperator("debug twin set"); err != nil {
+		return err
+	}
+	parts := strings.SplitN(assignment, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return errors.Errorf("%s is not a <property>=<value> assignment", assignment)
+	}
+	property, value := parts[0], parts[1]
+
+	payload, err := twinUpdatePayload(property, value, opts.Desired)
+	if err != nil {
+		return err
+	}
+	// the message is shaped like what eventbus builds when the mapper
+	// publishes to the twin update topic, so devicetwin cannot tell the
+	// difference
+	topic := dtcommon.DeviceETPrefix + device + dtcommon.TwinETUpdateSuffix
+	message := model.NewMessage("").
+		BuildRouter(modules.BusGroup, "user",
+			base64.URLEncoding.EncodeToString([]byte(topic)), "response").
+		FillBody(string(payload))
+
+	response, err := sendDebugRequest(opts.Socket, &debugserver.Request{
+		Op:      debugserver.OpInject,
+		Module:  constants.DeviceTwinModuleName,
+		Message: message,
+	})
+	if err != nil {
+		return err
+	}
+	if !response.Success {
+		return errors.Errorf("edgecore rejected the update: %s", response.Error)
+	}
+	kind := "reported"
+	if opts.Desired {
+		kind = "desired"
+	}
+	fmt.Fprintf(out, T("Sent %s value %s=%s for device %s\n"), kind, property, value, device)
+	fmt.Fprintf(out, T("Verify with: keadm debug get device %s --diff\n"), device)
+	return nil
+}
+
+// twinUpdatePayload builds the DeviceTwinUpdate document of one property
+func twinUpdatePayload(property, value string, desired bool) ([]byte, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	twinValue := &dttype.TwinValue{
+		Value:    &value,
+		Metadata: &dttype.ValueMetadata{Timestamp: now},
+	}
+	twin := &dttype.MsgTwin{
+		Metadata: &dttype.TypeMetadata{Type: "string"},
+	}
+	if desired {
+		twin.Expected = twinValue
+	} else {
+		twin.Actual = twinValue
+	}
+	update := dttype.DeviceTwinUpdate{
+		BaseMessage: dttype.BaseMessage{Timestamp: now},
+		Twin:        map[string]*dttype.MsgTwin{property: twin},
+	}
+	payload, err := json.Marshal(&update)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build the twin update")
+	}
+	return payload, nil
+}
@@ -0,0 +1,336 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var uiLongDescription = `
+    Browse the local edge database interactively: resource types on the
+    left, objects on the right, YAML, cleaned YAML and container logs one
+    keystroke away. Everything is served from the local database and the
+    container runtime, so the browser works on headless gateways without
+    cloud connectivity.
+
+    Keys: j/k or arrows move, tab switches panes, enter or y shows YAML,
+    d shows cleaned YAML, l shows container logs of a pod, r reloads,
+    q quits, and in a viewer j/k scroll and q goes back.
+`
+
+// uiResourceTypes is the left pane, the types keadm debug get understands
+var uiResourceTypes = []string{"pod", "node", "service", "secret", "configmap", "endpoints", "lease"}
+
+// UIOptions has the ui subcommand information filled by CLI
+type UIOptions struct {
+	EdgecoreConfig string
+	Input          string
+}
+
+// NewUI returns the cobra command starting the interactive browser
+func NewUI(out io.Writer) *cobra.Command {
+	opts := &UIOptions{EdgecoreConfig: DefaultEdgecoreConfigPath}
+	cmd := &cobra.Command{
+		Use:   "ui",
+		Short: "Browse the cached resources in an interactive terminal UI",
+		Long:  uiLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunUI(opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	return cmd
+}
+
+// uiState is everything the browser draws
+type uiState struct {
+	typeIndex   int
+	objectIndex int
+	// focus 0 is the type pane, 1 the object pane
+	focus   int
+	objects []dao.Meta
+	// viewer holds the lines of the open viewer, nil when browsing
+	viewer      []string
+	viewerTitle string
+	scroll      int
+	status      string
+}
+
+// RunUI drives the interactive browser until the user quits
+func RunUI(opts *UIOptions) error {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return errors.New("debug ui needs an interactive terminal")
+	}
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return err
+	}
+	previous, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return errors.Wrap(err, "failed to switch the terminal to raw mode")
+	}
+	// the alternate screen keeps the shell scrollback intact
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		terminal.Restore(fd, previous)
+	}()
+
+	state := &uiState{}
+	state.reload()
+	buf := make([]byte, 3)
+	for {
+		uiDraw(state)
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		if quit := uiHandleKey(state, buf[:n]); quit {
+			return nil
+		}
+	}
+}
+
+// reload fetches the objects of the selected type
+func (s *uiState) reload() {
+	s.objects = nil
+	s.objectIndex = 0
+	s.status = ""
+	metas, err := dao.QueryAllMeta("type", uiResourceTypes[s.typeIndex])
+	if err != nil {
+		s.status = err.Error()
+		return
+	}
+	s.objects = *metas
+	sort.Slice(s.objects, func(i, j int) bool { return s.objects[i].Key < s.objects[j].Key })
+}
+
+// uiHandleKey applies one keystroke, reporting whether the user quit
+func uiHandleKey(s *uiState, key []byte) bool {
+	up := len(key) == 3 && key[2] == 'A'
+	down := len(key) == 3 && key[2] == 'B'
+	if s.viewer != nil {
+		switch {
+		case len(key) == 1 && (key[0] == 'q' || key[0] == 0x1b):
+			s.viewer = nil
+		case len(key) == 1 && key[0] == 'j', down:
+			s.scroll++
+		case len(key) == 1 && (key[0] == 'k') || up:
+			if s.scroll > 0 {
+				s.scroll--
+			}
+		case len(key) == 1 && key[0] == 'g':
+			s.scroll = 0
+		case len(key) == 1 && key[0] == 'G':
+			s.scroll = len(s.viewer)
+		}
+		return false
+	}
+	switch {
+	case len(key) == 1 && (key[0] == 'q' || key[0] == 3):
+		return true
+	case len(key) == 1 && key[0] == '\t':
+		s.focus = 1 - s.focus
+	case len(key) == 1 && key[0] == 'j', down:
+		s.move(1)
+	case len(key) == 1 && key[0] == 'k', up:
+		s.move(-1)
+	case len(key) == 1 && key[0] == 'r':
+		s.reload()
+	case len(key) == 1 && (key[0] == 'y' || key[0] == '\r'):
+		s.open("yaml")
+	case len(key) == 1 && key[0] == 'd':
+		s.open("describe")
+	case len(key) == 1 && key[0] == 'l':
+		s.open("logs")
+	}
+	return false
+}
+
+// move shifts the selection of the focused pane
+func (s *uiState) move(delta int) {
+	if s.focus == 0 {
+		next := s.typeIndex + delta
+		if next >= 0 && next < len(uiResourceTypes) {
+			s.typeIndex = next
+			s.reload()
+		}
+		return
+	}
+	next := s.objectIndex + delta
+	if next >= 0 && next < len(s.objects) {
+		s.objectIndex = next
+	}
+}
+
+// open fills the viewer with the requested representation of the selection
+func (s *uiState) open(what string) {
+	if s.objectIndex >= len(s.objects) {
+		return
+	}
+	meta := s.objects[s.objectIndex]
+	content, err := uiContent(&meta, what)
+	if err != nil {
+		s.status = err.Error()
+		return
+	}
+	s.viewer = strings.Split(strings.ReplaceAll(content, "\t", "    "), "\n")
+	s.viewerTitle = fmt.Sprintf("%s %s", what, meta.Key)
+	s.scroll = 0
+}
+
+// uiContent renders one object as YAML, cleaned YAML or container logs
+func uiContent(meta *dao.Meta, what string) (string, error) {
+	switch what {
+	case "yaml":
+		data, err := yaml.JSONToYAML([]byte(meta.Value))
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case "describe":
+		data, err := yaml.JSONToYAML([]byte(cleanValue(meta.Value)))
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case "logs":
+		if meta.Type != "pod" {
+			return "", errors.New("logs only work on pods")
+		}
+		return uiPodLogs(meta.Key)
+	}
+	return "", errors.Errorf("unknown view %s", what)
+}
+
+// uiPodLogs fetches the recent logs of every container of the pod from
+// the container runtime
+func uiPodLogs(key string) (string, error) {
+	namespace, name := keyNamespace(key), keyName(key)
+	ids, err := exec.Command("docker", "ps", "-q",
+		"--filter", "label=io.kubernetes.pod.namespace="+namespace,
+		"--filter", "label=io.kubernetes.pod.name="+name).Output()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list the containers of the pod")
+	}
+	var logs []string
+	for _, id := range strings.Fields(string(ids)) {
+		output, err := exec.Command("docker", "logs", "--tail", "200", id).CombinedOutput()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to fetch the logs of container %s", id)
+		}
+		logs = append(logs, fmt.Sprintf("--- container %s ---", id), string(output))
+	}
+	if len(logs) == 0 {
+		return "no running containers for this pod", nil
+	}
+	return strings.Join(logs, "\n"), nil
+}
+
+// uiDraw paints one frame
+func uiDraw(s *uiState) {
+	width, height, err := terminal.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width < 20 || height < 4 {
+		width, height = 80, 24
+	}
+	var frame strings.Builder
+	frame.WriteString("\x1b[H\x1b[2J")
+	if s.viewer != nil {
+		uiDrawViewer(&frame, s, width, height)
+	} else {
+		uiDrawBrowser(&frame, s, width, height)
+	}
+	fmt.Print(frame.String())
+}
+
+// uiDrawBrowser paints the two pane browser
+func uiDrawBrowser(frame *strings.Builder, s *uiState, width, height int) {
+	left := 14
+	rows := height - 2
+	uiLine(frame, width, fmt.Sprintf(" keadm debug ui  %d %s cached", len(s.objects), uiResourceTypes[s.typeIndex]))
+	for row := 0; row < rows; row++ {
+		cell := strings.Repeat(" ", left)
+		if row < len(uiResourceTypes) {
+			cell = uiCell(uiResourceTypes[row], left, s.focus == 0 && row == s.typeIndex)
+		}
+		object := ""
+		if row < len(s.objects) {
+			object = uiCell(s.objects[row].Key, width-left-3, s.focus == 1 && row == s.objectIndex)
+		}
+		uiLine(frame, width, cell+" │ "+object)
+	}
+	status := s.status
+	if status == "" {
+		status = "j/k move  tab panes  y yaml  d describe  l logs  r reload  q quit"
+	}
+	uiLine(frame, width, " "+status)
+}
+
+// uiDrawViewer paints the full screen text viewer
+func uiDrawViewer(frame *strings.Builder, s *uiState, width, height int) {
+	rows := height - 2
+	if s.scroll > len(s.viewer)-rows {
+		s.scroll = len(s.viewer) - rows
+	}
+	if s.scroll < 0 {
+		s.scroll = 0
+	}
+	uiLine(frame, width, uiCell(" "+s.viewerTitle, width, true))
+	for row := 0; row < rows; row++ {
+		line := ""
+		if s.scroll+row < len(s.viewer) {
+			line = s.viewer[s.scroll+row]
+		}
+		uiLine(frame, width, line)
+	}
+	uiLine(frame, width, fmt.Sprintf(" line %d/%d  j/k scroll  q back", s.scroll+1, len(s.viewer)))
+}
+
+// uiCell pads or truncates one cell, optionally highlighted
+func uiCell(text string, width int, selected bool) string {
+	text = truncateCell(text, width)
+	text += strings.Repeat(" ", width-len([]rune(text)))
+	if selected {
+		return "\x1b[7m" + text + "\x1b[0m"
+	}
+	return text
+}
+
+// uiLine writes one screen line, raw mode needs the explicit \r. Lines
+// holding highlight escapes were already sized by uiCell
+func uiLine(frame *strings.Builder, width int, text string) {
+	if !strings.Contains(text, "\x1b") {
+		text = truncateCell(text, width)
+	}
+	frame.WriteString(text)
+	frame.WriteString("\r\n")
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/astaxie/beego/orm"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/common/dbm"
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+	"github.com/kubeedge/kubeedge/pkg/apis/componentconfig/edgecore/v1alpha1"
+)
+
+// DefaultEdgecoreConfigPath is the edgecore configuration read by the
+// debug subcommands unless --edgecore-config points elsewhere, the
+// platform files provide the per OS location
+const DefaultEdgecoreConfigPath = defaultEdgecoreConfigPath
+
+// LoadEdgecoreConfig reads the edgecore configuration from the given path,
+// falling back to built-in defaults for unset fields
+func LoadEdgecoreConfig(path string) (*v1alpha1.EdgeCoreConfig, error) {
+	config := v1alpha1.NewDefaultEdgeCoreConfig()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read edgecore config %s", path)
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse edgecore config %s", path)
+	}
+	return config, nil
+}
+
+// InitDB opens the edge metadata database described by the edgecore
+// configuration, dataSource (when not empty) overrides the configured
+// path, e.g. to inspect a copied database snapshot
+func InitDB(configPath, dataSource string) error {
+	// an explicit dataSource is a copied snapshot, worth an integrity
+	// check before it silently serves incomplete listings
+	snapshot := dataSource != ""
+	driverName := v1alpha1.DataBaseDriverName
+	aliasName := v1alpha1.DataBaseAliasName
+	if config, err := LoadEdgecoreConfig(configPath); err == nil {
+		driverName = config.DataBase.DriverName
+		aliasName = config.DataBase.AliasName
+		if dataSource == "" {
+			dataSource = config.DataBase.DataSource
+		}
+	} else if dataSource == "" {
+		return err
+	}
+	// fail with an actionable message before the orm turns a driver or
+	// permission problem into a stack trace deep inside its bootstrap
+	if err := checkDriverUsable(driverName); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(dataSource, ":memory:") && !strings.HasPrefix(dataSource, "file:") {
+		if err := checkReadable(dataSource); err != nil {
+			return errors.Wrap(err, "cannot open the edge database")
+		}
+	}
+	if snapshot {
+		checkSnapshotIntegrity(dataSource)
+	}
+	// snapshots from old nodes predate columns the ORM selects
+	// unconditionally; a legacy layout is upgraded on a temporary copy
+	// and the copy is what gets opened, the original stays untouched
+	if !strings.HasPrefix(dataSource, ":memory:") {
+		upgraded, err := ensureCompatibleLayout(dataSource)
+		if err != nil {
+			return err
+		}
+		dataSource = upgraded
+	}
+	// edgecore registers the models in the metamanager module, keadm has to
+	// do it itself before the orm can query the tables
+	orm.RegisterModel(new(dao.Meta))
+	orm.RegisterModel(new(dao.MetaBackup))
+	dbm.InitDBConfig(driverName, aliasName, dataSource)
+	return nil
+}
+
+// checkDriverUsable proves the compiled-in sqlite driver actually works
+// before the orm bootstrap runs. The driver needs cgo: a CGO_ENABLED=0
+// cross build (the usual way to produce a Windows binary) still compiles
+// but links a stub that fails on the first open, and there is no CGO-free
+// replacement compatible with the Go version this tree pins
+func checkDriverUsable(driverName string) error {
+	if driverName != "sqlite3" {
+		return nil
+	}
+	db, err := sql.Open(driverName, ":memory:")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		if strings.Contains(err.Error(), "CGO_ENABLED=0") {
+			return errors.New("this keadm was built without cgo, so its sqlite driver is a non-functional stub; rebuild with CGO_ENABLED=1 and a C toolchain for the target platform to use the database backed debug subcommands")
+		}
+		return errors.Wrap(err, "the sqlite driver is not usable")
+	}
+	return nil
+}
+
+// checkSnapshotIntegrity warns when a copied database looks mid-write:
+// a sidecar -wal or -journal file holds writes the copy may miss, and a
+// failed PRAGMA integrity_check means pages are torn. Listings still run,
+// the point is that incomplete output is no longer silent
+func checkSnapshotIntegrity(dataSource string) {
+	path := strings.TrimPrefix(dataSource, "file:")
+	if at := strings.IndexByte(path, '?'); at >= 0 {
+		path = path[:at]
+	}
+	for _, sidecar := range []string{"-wal", "-journal"} {
+		if info, err := os.Stat(path + sidecar); err == nil && info.Size() > 0 {
+			fmt.Fprintf(os.Stderr, T("warning: %s exists next to the snapshot, it was copied mid-write; copy the sidecar files along or re-copy after a checkpoint\n"), path+sidecar)
+		}
+	}
+	// open the same way dbm does, a WAL database refuses the check over
+	// a connection opened without WAL support
+	dsn := dataSource
+	if !strings.Contains(dsn, "_journal_mode=") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + "_journal_mode=WAL"
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return
+	}
+	defer db.Close()
+	verdict := ""
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&verdict); err != nil {
+		fmt.Fprintf(os.Stderr, T("warning: integrity check of the snapshot failed: %v\n"), err)
+		return
+	}
+	if verdict != "ok" {
+		fmt.Fprintf(os.Stderr, T("warning: the snapshot fails PRAGMA integrity_check (%s), listings may be incomplete\n"), verdict)
+	}
+}
+
+// humanDuration renders d the way kubectl renders ages, e.g. 105s, 14m, 5h, 3d
+func humanDuration(d time.Duration) string {
+	if d < 0 {
+		return "<invalid>"
+	}
+	switch {
+	case d < 2*time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < 2*time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 2*24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// timestampAge renders an RFC3339 timestamp column of a meta row as an
+// age, the columns were introduced late so rows written by older
+// edgecores miss them
+func timestampAge(timestamp string) string {
+	if timestamp == "" {
+		return "<unknown>"
+	}
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return "<invalid>"
+	}
+	return humanDuration(time.Since(t))
+}
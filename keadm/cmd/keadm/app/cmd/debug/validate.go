@@ -0,0 +1,269 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/kubeedge/kubeedge/edge/pkg/metamanager/dao"
+)
+
+var (
+	validateLongDescription = `
+    Check whether a pod manifest can actually run on this edge node before
+    it is deployed: resource requests against the node's allocatable,
+    existence of hostPath volumes, the architecture of referenced images
+    and volume types the edge does not support.
+`
+	validateExample = `
+    # Validate a pod manifest against this node
+    keadm debug validate -f pod.yaml
+`
+)
+
+// edgeSupportedVolumes are the volume types edged handles without a CSI
+// driver; anything else is flagged
+var edgeSupportedVolumes = []string{"hostPath", "emptyDir", "configMap", "secret", "downwardAPI", "projected"}
+
+// validateResult is one row of the validation report
+type validateResult struct {
+	check  string
+	ok     bool
+	detail string
+}
+
+// ValidateOptions has the validate subcommand information filled by CLI
+type ValidateOptions struct {
+	File           string
+	EdgecoreConfig string
+	Input          string
+}
+
+// NewValidateOptions returns validate options with defaults
+func NewValidateOptions() *ValidateOptions {
+	return &ValidateOptions{
+		EdgecoreConfig: DefaultEdgecoreConfigPath,
+	}
+}
+
+// NewValidate returns the cobra command validating manifests against the node
+func NewValidate(out io.Writer) *cobra.Command {
+	opts := NewValidateOptions()
+	cmd := &cobra.Command{
+		Use:     "validate",
+		Short:   "Check whether a pod manifest can run on this edge node",
+		Long:    validateLongDescription,
+		Example: validateExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return RunValidate(out, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.File, "file", "f", opts.File,
+		"Pod manifest to validate, - reads from stdin")
+	cmd.Flags().StringVar(&opts.EdgecoreConfig, "edgecore-config", opts.EdgecoreConfig,
+		"Path of the edgecore configuration used to locate the database")
+	cmd.Flags().StringVar(&opts.Input, "input", opts.Input,
+		"Path of a database file overriding the configured one")
+	return cmd
+}
+
+// RunValidate runs every feasibility check and prints the report
+func RunValidate(out io.Writer, opts *ValidateOptions) error {
+	if opts.File == "" {
+		return errors.New("a pod manifest is required, use -f")
+	}
+	var data []byte
+	var err error
+	if opts.File == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(opts.File)
+	}
+	if err != nil {
+		return err
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the manifest")
+	}
+	pod := v1.Pod{}
+	if err := json.Unmarshal(jsonData, &pod); err != nil {
+		return errors.Wrap(err, "the manifest is not a pod")
+	}
+	if !strings.EqualFold(pod.Kind, "Pod") {
+		return errors.Errorf("validate checks pod manifests, got kind %s", pod.Kind)
+	}
+
+	var results []validateResult
+	results = append(results, validateResources(&pod, opts)...)
+	results = append(results, validateHostPaths(&pod)...)
+	results = append(results, validateVolumes(&pod)...)
+	results = append(results, validateImages(&pod)...)
+
+	failed := 0
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, T("CHECK\tRESULT\tDETAIL"))
+	for _, result := range results {
+		verdict := "ok"
+		if !result.ok {
+			verdict = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.check, verdict, result.detail)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d checks failed, the pod will likely not run on this node", failed, len(results))
+	}
+	return nil
+}
+
+// validateResources compares the summed container requests with the
+// allocatable of the node cached in the edge database
+func validateResources(pod *v1.Pod, opts *ValidateOptions) []validateResult {
+	if err := InitDB(opts.EdgecoreConfig, opts.Input); err != nil {
+		return []validateResult{{check: "resource requests", ok: false, detail: err.Error()}}
+	}
+	metas, err := dao.QueryMeta("type", "node")
+	if err != nil || len(*metas) == 0 {
+		return []validateResult{{check: "resource requests", ok: true, detail: "no node object cached, skipped"}}
+	}
+	node := v1.Node{}
+	if err := json.Unmarshal([]byte((*metas)[0]), &node); err != nil {
+		return []validateResult{{check: "resource requests", ok: true, detail: "cached node object not parsable, skipped"}}
+	}
+
+	var results []validateResult
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		requested := resource.Quantity{}
+		for _, container := range pod.Spec.Containers {
+			if quantity, ok := container.Resources.Requests[name]; ok {
+				requested.Add(quantity)
+			}
+		}
+		allocatable, ok := node.Status.Allocatable[name]
+		if !ok {
+			continue
+		}
+		results = append(results, validateResult{
+			check:  fmt.Sprintf("%s requests", name),
+			ok:     requested.Cmp(allocatable) <= 0,
+			detail: fmt.Sprintf("requested %s, allocatable %s", requested.String(), allocatable.String()),
+		})
+	}
+	return results
+}
+
+// validateHostPaths checks that every hostPath volume exists on this node
+func validateHostPaths(pod *v1.Pod) []validateResult {
+	var results []validateResult
+	for _, volume := range pod.Spec.Volumes {
+		if volume.HostPath == nil {
+			continue
+		}
+		_, err := os.Stat(volume.HostPath.Path)
+		detail := volume.HostPath.Path
+		if err != nil {
+			detail = fmt.Sprintf("%s does not exist on this node", volume.HostPath.Path)
+		}
+		results = append(results, validateResult{
+			check:  fmt.Sprintf("hostPath %s", volume.Name),
+			ok:     err == nil,
+			detail: detail,
+		})
+	}
+	return results
+}
+
+// validateVolumes flags volume types edged cannot mount without a CSI driver
+func validateVolumes(pod *v1.Pod) []validateResult {
+	var results []validateResult
+	for _, volume := range pod.Spec.Volumes {
+		volumeType := volumeTypeName(&volume)
+		supported := false
+		for _, name := range edgeSupportedVolumes {
+			if volumeType == name {
+				supported = true
+				break
+			}
+		}
+		if supported {
+			continue
+		}
+		results = append(results, validateResult{
+			check:  fmt.Sprintf("volume %s", volume.Name),
+			ok:     false,
+			detail: fmt.Sprintf("volume type %s needs a CSI driver on the edge", volumeType),
+		})
+	}
+	return results
+}
+
+// volumeTypeName resolves the type of a volume from its set source field
+func volumeTypeName(volume *v1.Volume) string {
+	data, err := json.Marshal(volume.VolumeSource)
+	if err != nil {
+		return "unknown"
+	}
+	var source map[string]interface{}
+	if err := json.Unmarshal(data, &source); err != nil || len(source) == 0 {
+		return "unknown"
+	}
+	for name := range source {
+		return name
+	}
+	return "unknown"
+}
+
+// validateImages compares the architecture of locally pulled images with
+// this node; images not pulled yet cannot be checked offline
+func validateImages(pod *v1.Pod) []validateResult {
+	var results []validateResult
+	for _, container := range pod.Spec.Containers {
+		arch, err := exec.Command("docker", "image", "inspect", "--format", "{{.Architecture}}", container.Image).Output()
+		check := fmt.Sprintf("image %s", container.Image)
+		if err != nil {
+			results = append(results, validateResult{
+				check: check, ok: true, detail: "not pulled yet, architecture unknown",
+			})
+			continue
+		}
+		imageArch := strings.TrimSpace(string(arch))
+		results = append(results, validateResult{
+			check:  check,
+			ok:     imageArch == runtime.GOARCH,
+			detail: fmt.Sprintf("image is %s, node is %s", imageArch, runtime.GOARCH),
+		})
+	}
+	return results
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The KubeEdge Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// workloadAliases maps the command line names of the workload controller
+// types to their kind. The controllers themselves are not synced to the
+// edge database, the listings are derived from pod ownerReferences
+var workloadAliases = map[string]string{
+	"deployment": "Deployment", "deployments": "Deployment", "deploy": "Deployment",
+	"daemonset": "DaemonSet", "daemonsets": "DaemonSet", "ds": "DaemonSet",
+	"statefulset": "StatefulSet", "statefulsets": "StatefulSet", "sts": "StatefulSet",
+}
+
+// workloadRow aggregates the pods belonging to one controller
+type workloadRow struct {
+	namespace string
+	name      string
+	readyPods int
+	pods      int
+	oldest    time.Time
+}
+
+// runGetWorkloads lists the workload controllers owning the cached pods,
+// so operators see workloads instead of individual pods. The counts come
+// from the pods present on this node, not from the controller spec
+func runGetWorkloads(out io.Writer, opts *GetOptions, kind, name string) error {
+	if opts.Output == "json" || opts.Output == "yaml" {
+		return errors.Errorf("%s listings are derived from pod ownerReferences and have no stored object to print as %s", kind, opts.Output)
+	}
+	metas, err := queryMetas("pod", opts, "")
+	if err != nil {
+		return errors.Wrap(err, "failed to query the edge database")
+	}
+
+	pods := make([]*v1.Pod, len(*metas))
+	decodeErrs := make([]error, len(*metas))
+	forEachParallel(len(*metas), func(index int) {
+		pods[index], decodeErrs[index] = MetaToPod(&(*metas)[index])
+	})
+
+	rows := map[string]*workloadRow{}
+	for i := range pods {
+		if decodeErrs[i] != nil {
+			continue
+		}
+		owner := ownerWorkload(pods[i], kind)
+		if owner == "" || (name != "" && owner != name) {
+			continue
+		}
+		namespace := keyNamespace((*metas)[i].Key)
+		rowKey := namespace + "/" + owner
+		row, ok := rows[rowKey]
+		if !ok {
+			row = &workloadRow{namespace: namespace, name: owner}
+			rows[rowKey] = row
+		}
+		row.pods++
+		if podReady(pods[i]) {
+			row.readyPods++
+		}
+		created := pods[i].CreationTimestamp.Time
+		if row.oldest.IsZero() || (!created.IsZero() && created.Before(row.oldest)) {
+			row.oldest = created
+		}
+	}
+
+	sorted := make([]*workloadRow, 0, len(rows))
+	for _, row := range rows {
+		sorted = append(sorted, row)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].namespace != sorted[j].namespace {
+			return sorted[i].namespace < sorted[j].namespace
+		}
+		return sorted[i].name < sorted[j].name
+	})
+
+	if opts.Quiet {
+		for _, row := range sorted {
+			fmt.Fprintln(out, row.name)
+		}
+		return nil
+	}
+	if len(sorted) == 0 {
+		fmt.Fprintf(out, T("No resources found\n"))
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	header := "NAME\tREADY\tPODS\tAGE"
+	if opts.AllNamespaces {
+		header = "NAMESPACE\t" + header
+	}
+	fmt.Fprintln(w, header)
+	for _, row := range sorted {
+		age := "<unknown>"
+		if !row.oldest.IsZero() {
+			age = humanDuration(time.Since(row.oldest))
+		}
+		line := fmt.Sprintf("%s\t%d/%d\t%d\t%s", row.name, row.readyPods, row.pods, row.pods, age)
+		if opts.AllNamespaces {
+			line = row.namespace + "\t" + line
+		}
+		fmt.Fprintln(w, line)
+	}
+	return w.Flush()
+}
+
+// ownerWorkload resolves the controller of the given kind owning the pod.
+// Deployments own pods through a ReplicaSet, whose object is not on the
+// edge, so the deployment name is derived by stripping the pod-template
+// hash suffix from the ReplicaSet name
+func ownerWorkload(pod *v1.Pod, kind string) string {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == kind {
+			return owner.Name
+		}
+		if kind == "Deployment" && owner.Kind == "ReplicaSet" {
+			if index := strings.LastIndex(owner.Name, "-"); index > 0 {
+				return owner.Name[:index]
+			}
+		}
+	}
+	return ""
+}
+
+// podReady tells whether the pod has a true Ready condition
+func podReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
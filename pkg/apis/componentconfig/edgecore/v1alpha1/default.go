@@ -43,10 +43,15 @@ func NewDefaultEdgeCoreConfig() *EdgeCoreConfig {
 			APIVersion: path.Join(GroupName, APIVersion),
 		},
 		DataBase: &DataBase{
-			DriverName: DataBaseDriverName,
-			AliasName:  DataBaseAliasName,
-			DataSource: DataBaseDataSource,
+			DriverName:     DataBaseDriverName,
+			AliasName:      DataBaseAliasName,
+			DataSource:     DataBaseDataSource,
+			CompressValues: false,
 		},
+		EnableProfiling:    false,
+		ProfilingPort:      DefaultProfilingPort,
+		EnableMessageTrace: false,
+		MessageStatsFile:   DefaultMessageStatsFile,
 		Modules: &Modules{
 			Edged: &Edged{
 				Enable:                      true,
@@ -124,6 +129,7 @@ func NewDefaultEdgeCoreConfig() *EdgeCoreConfig {
 				ContextSendGroup:      metaconfig.GroupNameHub,
 				ContextSendModule:     metaconfig.ModuleNameEdgeHub,
 				PodStatusSyncInterval: constants.DefaultPodStatusSyncInterval,
+				StorageBackend:        "sqlite",
 			},
 			ServiceBus: &ServiceBus{
 				Enable: false,
@@ -51,6 +51,13 @@ const (
 	DataBaseDataSource = "/var/lib/kubeedge/edgecore.db"
 )
 
+const (
+	// DefaultProfilingPort is the default port of the localhost-only pprof server
+	DefaultProfilingPort = 10550
+	// DefaultMessageStatsFile is where beehive persists its message counters
+	DefaultMessageStatsFile = "/var/lib/kubeedge/message-stats.json"
+)
+
 type ProtocolName string
 type MqttMode int
 
@@ -63,6 +70,27 @@ type EdgeCoreConfig struct {
 	// Modules indicates EdgeCore modules config
 	// +Required
 	Modules *Modules `json:"modules,omitempty"`
+	// EnableProfiling indicates whether EdgeCore serves runtime profiling data
+	// via pprof on 127.0.0.1:ProfilingPort, only reachable from the node itself
+	// default false
+	EnableProfiling bool `json:"enableProfiling,omitempty"`
+	// ProfilingPort indicates the port the pprof server listens on when profiling is enabled
+	// default 10550
+	ProfilingPort int32 `json:"profilingPort,omitempty"`
+	// EnableMessageTrace indicates whether beehive records every message hop
+	// in an in-memory ring buffer, served via the profiling server
+	// default false
+	EnableMessageTrace bool `json:"enableMessageTrace,omitempty"`
+	// MessageStatsFile is where beehive periodically persists per-module
+	// message counters, readable via keadm debug stats,
+	// empty disables persistence
+	// default "/var/lib/kubeedge/message-stats.json"
+	MessageStatsFile string `json:"messageStatsFile,omitempty"`
+	// DebugServerSocket is the unix socket path of the local debug server
+	// used by keadm debug to interact with the running edgecore,
+	// empty disables the server
+	// default ""
+	DebugServerSocket string `json:"debugServerSocket,omitempty"`
 }
 
 // DataBase indicates the database info
@@ -76,6 +104,10 @@ type DataBase struct {
 	// DataSource indicates the data source path
 	// default "/var/lib/kubeedge/edge.db"
 	DataSource string `json:"dataSource,omitempty"`
+	// CompressValues indicates whether large stored object values are
+	// transparently compressed on write, trading CPU for disk space
+	// default false
+	CompressValues bool `json:"compressValues,omitempty"`
 }
 
 // Modules indicates the modules which edgeCore will be used
@@ -355,6 +387,12 @@ type MetaManager struct {
 	ContextSendModule metaconfig.ModuleName `json:"contextSendModule,omitempty"`
 	// PodStatusSyncInterval indicates pod status sync
 	PodStatusSyncInterval int32 `json:"podStatusSyncInterval,omitempty"`
+	// StorageBackend selects the store the cached objects live in:
+	// "sqlite" (the default) uses the database configured in DataBase,
+	// "bbolt" and "badger" use an embedded key-value store and need an
+	// edgecore built with the matching kvstore build tag
+	// default "sqlite"
+	StorageBackend string `json:"storageBackend,omitempty"`
 }
 
 // ServiceBus indicates the ServiceBus module config
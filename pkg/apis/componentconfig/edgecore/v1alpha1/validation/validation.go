@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
@@ -46,6 +47,10 @@ func ValidateEdgeCoreConfiguration(c *v1alpha1.EdgeCoreConfig) field.ErrorList {
 // ValidateDataBase validates `db` and returns an errorList if it is invalid
 func ValidateDataBase(db v1alpha1.DataBase) field.ErrorList {
 	allErrs := field.ErrorList{}
+	// in-memory databases have no backing directory to prepare
+	if db.DataSource == ":memory:" || strings.HasPrefix(db.DataSource, "file:") {
+		return allErrs
+	}
 	sourceDir := path.Dir(db.DataSource)
 	if !utilvalidation.FileIsExist(sourceDir) {
 		if err := os.MkdirAll(sourceDir, os.ModePerm); err != nil {
@@ -66,9 +66,12 @@ func (ctx *ChannelContext) Send(module string, message model.Message) {
 	}()
 
 	if channel := ctx.getChannel(module); channel != nil {
+		traceMessage("send", module, &message)
+		statsMessageOut(&message)
 		channel <- message
 		return
 	}
+	statsError(module)
 	klog.Warningf("Get bad module name :%s when send message, do nothing", module)
 }
 
@@ -76,6 +79,8 @@ func (ctx *ChannelContext) Send(module string, message model.Message) {
 func (ctx *ChannelContext) Receive(module string) (model.Message, error) {
 	if channel := ctx.getChannel(module); channel != nil {
 		content := <-channel
+		traceMessage("receive", module, &content)
+		statsMessageIn(module, &content)
 		return content, nil
 	}
 
@@ -108,13 +113,17 @@ func (ctx *ChannelContext) SendSync(module string, message model.Message, timeou
 	// check req/resp channel
 	reqChannel := ctx.getChannel(module)
 	if reqChannel == nil {
+		statsError(module)
 		return model.Message{}, fmt.Errorf("bad request module name(%s)", module)
 	}
 
 	sendTimer := time.NewTimer(timeout)
 	select {
 	case reqChannel <- message:
+		traceMessage("sendsync", module, &message)
+		statsMessageOut(&message)
 	case <-sendTimer.C:
+		statsError(module)
 		return model.Message{}, errors.New("timeout to send message")
 	}
 	sendTimer.Stop()
@@ -137,6 +146,7 @@ func (ctx *ChannelContext) SendSync(module string, message model.Message, timeou
 	select {
 	case resp = <-anonChan:
 	case <-respTimer.C:
+		statsError(module)
 		return model.Message{}, errors.New("timeout to get response")
 	}
 	respTimer.Stop()
@@ -151,6 +161,8 @@ func (ctx *ChannelContext) SendResp(message model.Message) {
 	ctx.anonChsLock.RLock()
 	defer ctx.anonChsLock.RUnlock()
 	if channel, exist := ctx.anonChannels[anonName]; exist {
+		traceMessage("response", message.GetSource(), &message)
+		statsMessageOut(&message)
 		channel <- message
 		return
 	}
@@ -179,11 +191,13 @@ func (ctx *ChannelContext) SendToGroup(moduleType string, message model.Message)
 		}
 	}
 	if channelList := ctx.getTypeChannel(moduleType); channelList != nil {
+		statsMessageOut(&message)
 		for _, channel := range channelList {
 			go send(channel)
 		}
 		return
 	}
+	statsError(moduleType)
 	klog.Warningf("Get bad module type:%s when sendToGroup message, do nothing", moduleType)
 }
 
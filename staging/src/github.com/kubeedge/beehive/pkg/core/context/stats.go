@@ -0,0 +1,145 @@
+package context
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+)
+
+// StatsFilePathDefault is where the message statistics are persisted
+// when no explicit path is configured
+const StatsFilePathDefault = "/var/lib/kubeedge/message-stats.json"
+
+// ModuleStats is the counter snapshot of one module
+type ModuleStats struct {
+	Module      string `json:"module"`
+	MessagesIn  int64  `json:"messagesIn"`
+	MessagesOut int64  `json:"messagesOut"`
+	// AvgDispatchLatency is the mean time between building a message and
+	// its delivery to this module, in nanoseconds
+	AvgDispatchLatency time.Duration `json:"avgDispatchLatency"`
+	Errors             int64         `json:"errors"`
+}
+
+// MessageStats is the persisted form of the statistics
+type MessageStats struct {
+	UpdatedAt time.Time     `json:"updatedAt"`
+	Modules   []ModuleStats `json:"modules"`
+}
+
+// moduleCounters accumulates for one module, guarded by the collector lock
+type moduleCounters struct {
+	messagesIn   int64
+	messagesOut  int64
+	totalLatency time.Duration
+	errors       int64
+}
+
+// statsCollector keeps the per-module counters; collection is always on,
+// the counters are cheap compared to the channel operations they count
+type statsCollector struct {
+	lock    sync.Mutex
+	modules map[string]*moduleCounters
+}
+
+var stats = statsCollector{modules: make(map[string]*moduleCounters)}
+
+// counters returns the entry of module, creating it, callers hold the lock
+func (c *statsCollector) counters(module string) *moduleCounters {
+	entry, exist := c.modules[module]
+	if !exist {
+		entry = &moduleCounters{}
+		c.modules[module] = entry
+	}
+	return entry
+}
+
+// statsMessageIn counts a delivery to module with its dispatch latency
+func statsMessageIn(module string, message *model.Message) {
+	latency := time.Since(time.Unix(0, message.GetTimestamp()*int64(time.Millisecond)))
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	entry := stats.counters(module)
+	entry.messagesIn++
+	if latency > 0 {
+		entry.totalLatency += latency
+	}
+}
+
+// statsMessageOut counts a message sent by the module named in its router
+func statsMessageOut(message *model.Message) {
+	source := message.GetSource()
+	if source == "" {
+		return
+	}
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	stats.counters(source).messagesOut++
+}
+
+// statsError counts a delivery failure attributed to module
+func statsError(module string) {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	stats.counters(module).errors++
+}
+
+// MessageStatsSnapshot returns the current counters, sorted by module
+func MessageStatsSnapshot() MessageStats {
+	stats.lock.Lock()
+	defer stats.lock.Unlock()
+	snapshot := MessageStats{UpdatedAt: time.Now()}
+	for module, entry := range stats.modules {
+		moduleStats := ModuleStats{
+			Module:      module,
+			MessagesIn:  entry.messagesIn,
+			MessagesOut: entry.messagesOut,
+			Errors:      entry.errors,
+		}
+		if entry.messagesIn > 0 {
+			moduleStats.AvgDispatchLatency = entry.totalLatency / time.Duration(entry.messagesIn)
+		}
+		snapshot.Modules = append(snapshot.Modules, moduleStats)
+	}
+	sort.Slice(snapshot.Modules, func(i, j int) bool {
+		return snapshot.Modules[i].Module < snapshot.Modules[j].Module
+	})
+	return snapshot
+}
+
+// StartStatsPersistence periodically writes the statistics to path until
+// the framework shuts down, so they can be read outside the process
+func StartStatsPersistence(path string, interval time.Duration) {
+	for {
+		select {
+		case <-Done():
+			persistStats(path)
+			return
+		case <-time.After(interval):
+			persistStats(path)
+		}
+	}
+}
+
+// persistStats writes the snapshot atomically, failures only log
+func persistStats(path string) {
+	data, err := json.MarshalIndent(MessageStatsSnapshot(), "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		klog.Warningf("failed to persist message statistics: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		klog.Warningf("failed to persist message statistics: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package context
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kubeedge/beehive/pkg/core/model"
+)
+
+// TraceCapacityDefault is the number of records kept when tracing is enabled
+// without an explicit capacity
+const TraceCapacityDefault = 1024
+
+// TraceRecord is one observed hop of a message through the framework
+type TraceRecord struct {
+	ID        string        `json:"id"`
+	ParentID  string        `json:"parent_id,omitempty"`
+	Event     string        `json:"event"`
+	Module    string        `json:"module"`
+	Source    string        `json:"source"`
+	Group     string        `json:"group,omitempty"`
+	Operation string        `json:"operation"`
+	Resource  string        `json:"resource"`
+	Timestamp time.Time     `json:"timestamp"`
+	Latency   time.Duration `json:"latency"`
+}
+
+// messageTracer keeps the most recent trace records in a fixed size ring buffer
+type messageTracer struct {
+	lock     sync.Mutex
+	enabled  bool
+	records  []TraceRecord
+	next     int
+	wrapped  bool
+	capacity int
+}
+
+var tracer messageTracer
+
+// EnableMessageTrace turns on recording of message hops, keeping at most
+// capacity records (TraceCapacityDefault when capacity <= 0)
+func EnableMessageTrace(capacity int) {
+	if capacity <= 0 {
+		capacity = TraceCapacityDefault
+	}
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	tracer.enabled = true
+	tracer.capacity = capacity
+	tracer.records = make([]TraceRecord, capacity)
+	tracer.next = 0
+	tracer.wrapped = false
+}
+
+// traceMessage records one hop of message into the ring buffer, it is a
+// no-op unless EnableMessageTrace has been called
+func traceMessage(event, module string, message *model.Message) {
+	if !tracer.enabled {
+		return
+	}
+	now := time.Now()
+	record := TraceRecord{
+		ID:        message.GetID(),
+		ParentID:  message.GetParentID(),
+		Event:     event,
+		Module:    module,
+		Source:    message.GetSource(),
+		Group:     message.GetGroup(),
+		Operation: message.GetOperation(),
+		Resource:  message.GetResource(),
+		Timestamp: now,
+		Latency:   now.Sub(time.Unix(0, message.GetTimestamp()*int64(time.Millisecond))),
+	}
+
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	tracer.records[tracer.next] = record
+	tracer.next++
+	if tracer.next == tracer.capacity {
+		tracer.next = 0
+		tracer.wrapped = true
+	}
+}
+
+// MessageTraceRecords returns the recorded hops, oldest first
+func MessageTraceRecords() []TraceRecord {
+	tracer.lock.Lock()
+	defer tracer.lock.Unlock()
+	if !tracer.enabled {
+		return nil
+	}
+	var records []TraceRecord
+	if tracer.wrapped {
+		records = append(records, tracer.records[tracer.next:]...)
+	}
+	records = append(records, tracer.records[:tracer.next]...)
+	return records
+}